@@ -19,7 +19,7 @@ func run() model.ExitCode {
 		exitCode, cause := model.ExitCodeFromError(err)
 		if cause != nil {
 			logrus.WithError(cause).Error("gmenu exited with error")
-		} else {
+		} else if !exitCode.IsAccept() {
 			logrus.WithField("exit_code", exitCode).Error("gmenu exited with error")
 		}
 		return exitCode
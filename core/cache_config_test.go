@@ -2,6 +2,7 @@ package core
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hamidzr/gmenu/model"
@@ -489,6 +490,22 @@ func TestClearCacheFunction(t *testing.T) {
 	assert.Equal(t, "", cache.LastEntry)
 }
 
+// TestStateDir tests that the configured state directory holds the cache
+func TestStateDir(t *testing.T) {
+	stateDir := t.TempDir()
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{MenuID: "state-dir-test", StateDir: stateDir})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NoError(t, gmenu.SetupMenu([]string{"item1", "item2"}, ""))
+
+	require.NoError(t, gmenu.cacheState("item1"))
+	assert.FileExists(t, filepath.Join(stateDir, "state-dir-test", "cache.yaml"))
+}
+
 // TestCanBeHighlightedWithCacheValues tests canBeHighlighted with realistic cache values
 func TestCanBeHighlightedWithCacheValues(t *testing.T) {
 	tests := []struct {
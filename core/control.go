@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Commands a second invocation sends to the running instance of its menu ID.
+const (
+	controlFocus  = "focus"
+	controlToggle = "toggle"
+	controlQuit   = "quit"
+)
+
+const (
+	// controlTimeout bounds a round trip on the control socket.
+	controlTimeout = 2 * time.Second
+	// replaceTimeout is how long a replacing invocation waits for the
+	// running instance to exit.
+	replaceTimeout = 3 * time.Second
+	// replacePollInterval is how often the lock is retried meanwhile.
+	replacePollInterval = 20 * time.Millisecond
+)
+
+// ErrHandedOff is returned by ClaimInstance and RunAppForever when the
+// running instance of the menu ID handled the invocation, as asked by the
+// on_conflict policy.
+var ErrHandedOff = errors.New("handed off to the running instance")
+
+// validateOnConflict checks the on_conflict policy.
+func validateOnConflict(policy string) error {
+	switch policy {
+	case "", model.OnConflictFail, model.OnConflictFocus, model.OnConflictReplace, model.OnConflictToggle:
+		return nil
+	}
+	return fmt.Errorf("invalid on_conflict policy %q: expected %s, %s, %s or %s", policy,
+		model.OnConflictFail, model.OnConflictFocus, model.OnConflictReplace, model.OnConflictToggle)
+}
+
+// controlSocketPath returns the socket the running instance of menuID
+// listens on, next to its lock file.
+func controlSocketPath(menuID string) string {
+	if menuID == "" {
+		menuID = defaultPidFileName
+	}
+	return filepath.Join(os.TempDir(), menuID+"-control.sock")
+}
+
+// ClaimInstance takes the instance lock of conf's menu ID. When another
+// instance holds it, the on_conflict policy decides whether to fail, hand the
+// invocation off to it (ErrHandedOff) or replace it. Call it before reading
+// input or building the window so a handed off invocation does neither.
+func ClaimInstance(conf *model.Config) (*InstanceLock, error) {
+	if err := validateOnConflict(conf.OnConflict); err != nil {
+		return nil, err
+	}
+	lock, err := AcquireInstanceLock(conf.MenuID)
+	if !errors.Is(err, ErrInstanceLocked) {
+		return lock, err
+	}
+	var command string
+	switch conf.OnConflict {
+	case model.OnConflictFocus:
+		command = controlFocus
+	case model.OnConflictToggle:
+		command = controlToggle
+	case model.OnConflictReplace:
+		command = controlQuit
+	default:
+		return nil, err
+	}
+	if sendErr := sendControl(conf.MenuID, command); sendErr != nil {
+		return nil, fmt.Errorf("%w; failed to reach it: %v", err, sendErr)
+	}
+	if command != controlQuit {
+		return nil, ErrHandedOff
+	}
+	deadline := time.Now().Add(replaceTimeout)
+	for {
+		lock, err = AcquireInstanceLock(conf.MenuID)
+		if !errors.Is(err, ErrInstanceLocked) || time.Now().After(deadline) {
+			return lock, err
+		}
+		time.Sleep(replacePollInterval)
+	}
+}
+
+// sendControl sends command to the running instance of menuID and waits for
+// it to be handled.
+func sendControl(menuID, command string) error {
+	conn, err := net.DialTimeout("unix", controlSocketPath(menuID), controlTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(controlTimeout))
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return errors.New(strings.TrimPrefix(reply, "error: "))
+	}
+	return nil
+}
+
+// serveControl listens for commands from later invocations of the menu ID.
+// It must be called while holding the instance lock, which makes any socket
+// left at the path stale. The returned function stops listening.
+func (g *GMenu) serveControl() (func(), error) {
+	path := controlSocketPath(g.menuID)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	// only the owner may show or hide the menu
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict control socket permissions: %w", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go g.handleControlConn(conn)
+		}
+	}()
+	return func() { _ = listener.Close() }, nil
+}
+
+func (g *GMenu) handleControlConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(controlTimeout))
+	command, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	reply := "ok"
+	if err := g.handleControl(strings.TrimSpace(command)); err != nil {
+		reply = "error: " + err.Error()
+	}
+	if _, err := fmt.Fprintln(conn, reply); err != nil {
+		logrus.WithError(err).Debug("failed to answer control command")
+	}
+}
+
+// handleControl runs a command from a later invocation of the menu ID.
+func (g *GMenu) handleControl(command string) error {
+	logrus.Debug("control command: ", command)
+	switch command {
+	case controlFocus:
+		if err := g.ShowUI(); err != nil {
+			return err
+		}
+		g.safeUIUpdate(func() {
+			g.ui.MainWindow.RequestFocus()
+		})
+	case controlToggle:
+		if g.IsShown() {
+			// closing the menu cancels it, like pressing escape
+			_ = g.SetExitCode(model.UserCanceled)
+			g.HideUI()
+			return nil
+		}
+		if g.selectionFuse.IsBroken() {
+			// nothing is waiting for a selection, e.g. an idle daemon
+			return nil
+		}
+		return g.ToggleVisibility()
+	case controlQuit:
+		g.QuitWithCode(model.UserCanceled)
+	default:
+		return fmt.Errorf("unknown control command %q", command)
+	}
+	return nil
+}
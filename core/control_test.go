@@ -0,0 +1,104 @@
+package core
+
+import (
+	"os"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConflictMenu creates a menu with the given on_conflict policy.
+func newConflictMenu(t *testing.T, menuID, policy string) *GMenu {
+	t.Helper()
+	gmenu, err := NewGMenuWithApp(test.NewApp(), DirectSearch, &model.Config{MenuID: menuID, OnConflict: policy})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	return gmenu
+}
+
+// runningMenu starts a menu that holds the instance lock of menuID and
+// listens for control commands, as RunAppForever does.
+func runningMenu(t *testing.T, menuID string) (*GMenu, *InstanceLock) {
+	t.Helper()
+	gmenu := newConflictMenu(t, menuID, "")
+	require.NoError(t, gmenu.SetupMenu([]string{"one", "two"}, ""))
+	lock, err := AcquireInstanceLock(menuID)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lock.Release() })
+	stop, err := gmenu.serveControl()
+	require.NoError(t, err)
+	t.Cleanup(stop)
+	return gmenu, lock
+}
+
+func TestControlSocketPermissions(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	useFyneTestApp(t)
+	runningMenu(t, "control-perms")
+	info, err := os.Stat(controlSocketPath("control-perms"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestOnConflict(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	useFyneTestApp(t)
+
+	t.Run("fail", func(t *testing.T) {
+		runningMenu(t, "conflict-fail")
+		_, err := ClaimInstance(&model.Config{MenuID: "conflict-fail", OnConflict: model.OnConflictFail})
+		assert.ErrorIs(t, err, ErrInstanceLocked)
+	})
+
+	t.Run("focus-existing shows the running menu", func(t *testing.T) {
+		running, _ := runningMenu(t, "conflict-focus")
+		_, err := ClaimInstance(&model.Config{MenuID: "conflict-focus", OnConflict: model.OnConflictFocus})
+		assert.ErrorIs(t, err, ErrHandedOff)
+		assert.True(t, running.IsShown())
+		assert.Equal(t, model.Unset, running.GetExitCode())
+	})
+
+	t.Run("toggle closes a shown menu", func(t *testing.T) {
+		running, _ := runningMenu(t, "conflict-toggle")
+		require.NoError(t, running.ShowUI())
+		_, err := ClaimInstance(&model.Config{MenuID: "conflict-toggle", OnConflict: model.OnConflictToggle})
+		assert.ErrorIs(t, err, ErrHandedOff)
+		running.WaitForSelection()
+		assert.Equal(t, model.UserCanceled, running.GetExitCode())
+		assert.False(t, running.IsShown())
+	})
+
+	t.Run("toggle shows a hidden menu", func(t *testing.T) {
+		running, _ := runningMenu(t, "conflict-show")
+		_, err := ClaimInstance(&model.Config{MenuID: "conflict-show", OnConflict: model.OnConflictToggle})
+		assert.ErrorIs(t, err, ErrHandedOff)
+		assert.True(t, running.IsShown())
+	})
+
+	t.Run("replace cancels the running menu and takes over", func(t *testing.T) {
+		running, lock := runningMenu(t, "conflict-replace")
+		go func() {
+			// the running menu exits once cancelled
+			running.WaitForSelection()
+			_ = lock.Release()
+		}()
+		secondLock, err := ClaimInstance(&model.Config{MenuID: "conflict-replace", OnConflict: model.OnConflictReplace})
+		require.NoError(t, err)
+		require.NoError(t, secondLock.Release())
+		assert.Equal(t, model.UserCanceled, running.GetExitCode())
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := NewGMenu(DirectSearch, &model.Config{OnConflict: "ignore"})
+		assert.Error(t, err)
+		_, err = ClaimInstance(&model.Config{MenuID: "conflict-invalid", OnConflict: "ignore"})
+		assert.Error(t, err)
+	})
+}
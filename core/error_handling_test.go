@@ -386,22 +386,21 @@ func TestFileOperationErrors(t *testing.T) {
 	require.NoError(t, err)
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 
-	// Test createPidFile with valid directory
-	pidFile, err := createPidFile("test_pid")
+	// Test the instance lock with valid directory
+	lock, err := AcquireInstanceLock("test_pid")
 	if err == nil {
 		defer func() {
-			_ = RemovePidFile("test_pid")
+			_ = lock.Release()
 		}()
-		assert.NotEmpty(t, pidFile)
 
-		// Test creating PID file when one already exists
-		_, err = createPidFile("test_pid")
+		// Test taking the lock while it is held
+		_, err = AcquireInstanceLock("test_pid")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "already exists")
+		assert.ErrorIs(t, err, ErrInstanceLocked)
 	}
 
-	// Test removePidFile with non-existent file
-	err = RemovePidFile("nonexistent_pid")
+	// Test clearing a lock that doesn't exist
+	err = ClearInstanceLock("nonexistent_pid")
 	assert.NoError(t, err)
 }
 
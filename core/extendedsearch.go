@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hamidzr/gmenu/model"
+)
+
+// termKind is how a term of an extended query matches an item.
+type termKind int
+
+const (
+	termFuzzy termKind = iota
+	termExact
+	termPrefix
+	termSuffix
+	termEqual
+)
+
+// extendedTerm is a single term of an extended query, e.g. "^src" or "!vendor".
+type extendedTerm struct {
+	text   string
+	kind   termKind
+	negate bool
+}
+
+// parseExtendedQuery splits a query into groups of terms. Every group has
+// to match, and a group matches when any of its terms does. Terms are
+// separated by spaces and a "|" between two terms puts them in one group.
+func parseExtendedQuery(query string) [][]extendedTerm {
+	var groups [][]extendedTerm
+	or := false
+	for _, token := range strings.Fields(query) {
+		if token == "|" {
+			or = len(groups) > 0
+			continue
+		}
+		term, ok := parseExtendedTerm(token)
+		if !ok {
+			continue
+		}
+		if or {
+			groups[len(groups)-1] = append(groups[len(groups)-1], term)
+		} else {
+			groups = append(groups, []extendedTerm{term})
+		}
+		or = false
+	}
+	return groups
+}
+
+// parseExtendedTerm parses the operators of a term the way fzf does:
+// 'exact, ^prefix, suffix$, ^equal$ and a leading ! to negate any of them.
+// Negated terms without another operator match exactly. A term that is only
+// operators is ignored.
+func parseExtendedTerm(token string) (extendedTerm, bool) {
+	term := extendedTerm{kind: termFuzzy}
+	if rest, ok := strings.CutPrefix(token, "!"); ok {
+		term.negate = true
+		term.kind = termExact
+		token = rest
+	}
+	if rest, ok := strings.CutPrefix(token, "'"); ok {
+		term.kind = termExact
+		token = rest
+	} else if rest, ok := strings.CutPrefix(token, "^"); ok {
+		term.kind = termPrefix
+		token = rest
+	}
+	if rest, ok := strings.CutSuffix(token, "$"); ok && rest != "" {
+		if term.kind == termPrefix {
+			term.kind = termEqual
+		} else {
+			term.kind = termSuffix
+		}
+		token = rest
+	}
+	term.text = token
+	return term, token != ""
+}
+
+// match reports whether text matches the term prepared as q, ignoring the
+// negation, and returns the matched range. Fuzzy terms are matched by the
+// search method instead. Case is ignored unless the term has upper case
+// letters.
+func (t extendedTerm) match(q textQuery, text *model.SearchText) (model.MatchRange, bool) {
+	if t.kind == termExact {
+		return q.contains(text)
+	}
+	if !text.MayContain(q.chars) {
+		return model.MatchRange{}, false
+	}
+	s, sub := q.operands(text)
+	subLen := utf8.RuneCountInString(sub)
+	switch t.kind {
+	case termPrefix:
+		if strings.HasPrefix(s, sub) {
+			return model.MatchRange{Start: 0, End: subLen}, true
+		}
+	case termSuffix:
+		if strings.HasSuffix(s, sub) {
+			sLen := utf8.RuneCountInString(s)
+			return model.MatchRange{Start: sLen - subLen, End: sLen}, true
+		}
+	case termEqual:
+		if s == sub {
+			return model.MatchRange{Start: 0, End: subLen}, true
+		}
+	}
+	return model.MatchRange{}, false
+}
+
+// search returns the items matching the term, in the order of method for
+// fuzzy terms and in input order otherwise.
+func (t extendedTerm) search(ctx context.Context, items []model.MenuItem, method SearchMethod, preserveOrder bool) []model.MenuItem {
+	if t.kind == termFuzzy && !t.negate {
+		return method(ctx, items, t.text, preserveOrder, 0)
+	}
+	q := newTextQuery(t.text, true)
+	return matchItems(ctx, items, func(item *model.MenuItem) (model.MenuItem, bool) {
+		r, ok := t.match(q, item.SearchText())
+		if ok == t.negate {
+			return model.MenuItem{}, false
+		}
+		matched := *item
+		matched.Matches = nil
+		if ok {
+			matched.Matches = []model.MatchRange{r}
+		}
+		return matched, true
+	})
+}
+
+// ExtendedSearch supports fzf's extended search syntax on top of method:
+//
+//	term      matched by method, usually fuzzy
+//	'term     contains term
+//	^term     starts with term
+//	term$     ends with term
+//	^term$    is term
+//	!term     doesn't contain term, also !^term and !term$
+//	a | b     matches a or b
+//
+// Space separated terms all have to match.
+func ExtendedSearch(method SearchMethod) SearchMethod {
+	return func(ctx context.Context, items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
+		matched := items
+		for _, group := range parseExtendedQuery(query) {
+			if ctx.Err() != nil {
+				return nil
+			}
+			matched = searchExtendedGroup(ctx, matched, group, method, preserveOrder)
+		}
+		return applyLimit(matched, limit)
+	}
+}
+
+// searchExtendedGroup returns the items matching any term of group, keeping
+// the ranges matched by earlier groups.
+func searchExtendedGroup(ctx context.Context, items []model.MenuItem, group []extendedTerm, method SearchMethod, preserveOrder bool) []model.MenuItem {
+	results := make([]model.MenuItem, 0)
+	index := make(map[string]int)
+	for _, term := range group {
+		for _, item := range term.search(ctx, items, method, preserveOrder) {
+			key := item.Key()
+			if i, ok := index[key]; ok {
+				results[i].Matches = mergeMatchRanges(results[i].Matches, item.Matches)
+				continue
+			}
+			index[key] = len(results)
+			results = append(results, item)
+		}
+	}
+	for i := range items {
+		if len(items[i].Matches) == 0 {
+			continue
+		}
+		if j, ok := index[items[i].Key()]; ok {
+			results[j].Matches = mergeMatchRanges(items[i].Matches, results[j].Matches)
+		}
+	}
+	return results
+}
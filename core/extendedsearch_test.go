@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExtendedTerm(t *testing.T) {
+	tests := []struct {
+		token    string
+		expected extendedTerm
+		ok       bool
+	}{
+		{"src", extendedTerm{text: "src", kind: termFuzzy}, true},
+		{"'src", extendedTerm{text: "src", kind: termExact}, true},
+		{"^src", extendedTerm{text: "src", kind: termPrefix}, true},
+		{".go$", extendedTerm{text: ".go", kind: termSuffix}, true},
+		{"^main.go$", extendedTerm{text: "main.go", kind: termEqual}, true},
+		{"!vendor", extendedTerm{text: "vendor", kind: termExact, negate: true}, true},
+		{"!^test", extendedTerm{text: "test", kind: termPrefix, negate: true}, true},
+		{"!_test.go$", extendedTerm{text: "_test.go", kind: termSuffix, negate: true}, true},
+		{"$", extendedTerm{text: "$", kind: termFuzzy}, true},
+		{"!", extendedTerm{kind: termExact, negate: true}, false},
+		{"^", extendedTerm{kind: termPrefix}, false},
+		{"'", extendedTerm{kind: termExact}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			term, ok := parseExtendedTerm(tt.token)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, term)
+		})
+	}
+}
+
+func TestParseExtendedQuery(t *testing.T) {
+	groups := parseExtendedQuery("  ^core go$ | rb$ | py$ !vendor | ")
+	assert.Equal(t, [][]extendedTerm{
+		{{text: "core", kind: termPrefix}},
+		{{text: "go", kind: termSuffix}, {text: "rb", kind: termSuffix}, {text: "py", kind: termSuffix}},
+		{{text: "vendor", kind: termExact, negate: true}},
+	}, groups)
+	assert.Empty(t, parseExtendedQuery("| ! ^"))
+}
+
+func TestExtendedSearch(t *testing.T) {
+	titles := []string{
+		"src/main.go",
+		"src/main_test.go",
+		"vendor/lib/lib.go",
+		"node_modules/pkg/index.js",
+		"scripts/build.py",
+		"README.md",
+	}
+	search := SearchMethods["extended"]
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"empty query", "", titles},
+		{"fuzzy", "mago", []string{"src/main.go", "src/main_test.go"}},
+		{"exact", "'main", []string{"src/main.go", "src/main_test.go"}},
+		{"exact needs adjacent characters", "'mago", []string{}},
+		{"prefix", "^src", []string{"src/main.go", "src/main_test.go"}},
+		{"suffix", ".go$", []string{"src/main.go", "src/main_test.go", "vendor/lib/lib.go"}},
+		{"equal", "^readme.md$", []string{"README.md"}},
+		{"negation", "!vendor !node_modules go", []string{"src/main.go", "src/main_test.go"}},
+		{"negated prefix", "!^src .go$", []string{"vendor/lib/lib.go"}},
+		{"negated suffix", "^src !_test.go$", []string{"src/main.go"}},
+		{"or", "py$ | js$", []string{"scripts/build.py", "node_modules/pkg/index.js"}},
+		{"or with and", "!^node py$ | js$", []string{"scripts/build.py"}},
+		{"smart case", "^Src", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, itemsToStr(search(context.Background(), strToItems(&titles), tt.query, false, 0)))
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		assert.Len(t, search(context.Background(), strToItems(&titles), ".go$", false, 2), 2)
+	})
+
+	t.Run("match ranges", func(t *testing.T) {
+		results := search(context.Background(), strToItems(&titles), "^src go$", false, 0)
+		assert.Equal(t, []model.MatchRange{{Start: 0, End: 3}, {Start: 9, End: 11}}, results[0].Matches)
+		results = search(context.Background(), strToItems(&titles), "!vendor ^src", false, 0)
+		assert.Equal(t, []model.MatchRange{{Start: 0, End: 3}}, results[0].Matches)
+	})
+
+	t.Run("direct terms", func(t *testing.T) {
+		direct := ExtendedSearch(DirectSearch)
+		assert.Empty(t, direct(context.Background(), strToItems(&titles), "mago", false, 0))
+		assert.Equal(t, []string{"scripts/build.py"}, itemsToStr(direct(context.Background(), strToItems(&titles), "build !^src", false, 0)))
+	})
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/store"
+)
+
+// Frecency ranks entries by how often and how recently they were accepted.
+type Frecency struct {
+	UsageCount map[string]int
+	LastUsed   map[string]int64
+	Now        time.Time
+}
+
+// NewFrecency builds frecency stats from a menu cache.
+func NewFrecency(cache store.Cache, now time.Time) Frecency {
+	return Frecency{
+		UsageCount: cache.UsageCount,
+		LastUsed:   cache.LastUsed,
+		Now:        now,
+	}
+}
+
+// IsEmpty reports whether there is no usage history to rank by.
+func (f Frecency) IsEmpty() bool {
+	return len(f.UsageCount) == 0
+}
+
+// Score returns the frecency score of an entry. Unused entries score 0.
+func (f Frecency) Score(entry string) float64 {
+	count := f.UsageCount[entry]
+	if count <= 0 {
+		return 0
+	}
+	// recent selections weigh more, similar to browser history ranking.
+	age := f.Now.Sub(time.Unix(f.LastUsed[entry], 0))
+	var weight float64
+	switch {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 1
+	case age < 30*24*time.Hour:
+		weight = 0.5
+	default:
+		weight = 0.25
+	}
+	return float64(count) * weight
+}
+
+// Sort returns the items ordered by descending frecency.
+// Items that were never used keep their input order after the ranked ones.
+func (f Frecency) Sort(items []model.MenuItem) []model.MenuItem {
+	if f.IsEmpty() {
+		return items
+	}
+	type rankedItem struct {
+		item  model.MenuItem
+		score float64
+	}
+	ranked := make([]rankedItem, 0)
+	for _, item := range items {
+		if score := f.Score(item.Key()); score > 0 {
+			ranked = append(ranked, rankedItem{item: item, score: score})
+		}
+	}
+	if len(ranked) == 0 {
+		return items
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	sorted := make([]model.MenuItem, 0, len(items))
+	for _, r := range ranked {
+		sorted = append(sorted, r.item)
+	}
+	for _, item := range items {
+		if f.Score(item.Key()) <= 0 {
+			sorted = append(sorted, item)
+		}
+	}
+	return sorted
+}
+
+// FrecencySearch wraps a search method so that results follow frecency order.
+// Items are ranked before being handed to the wrapped method, which keeps input
+// order among equally good matches, so frecency decides ties. Empty queries
+// return every item in frecency order.
+func FrecencySearch(frecency Frecency, searchMethod SearchMethod) SearchMethod {
+	return func(ctx context.Context, items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
+		if preserveOrder {
+			return searchMethod(ctx, items, query, preserveOrder, limit)
+		}
+		ranked := frecency.Sort(items)
+		if query == "" {
+			return applyLimit(ranked, limit)
+		}
+		return searchMethod(ctx, ranked, query, preserveOrder, limit)
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrecencyScore(t *testing.T) {
+	now := time.Now()
+	frecency := Frecency{
+		UsageCount: map[string]int{"recent": 2, "old": 5, "stale": 1},
+		LastUsed: map[string]int64{
+			"recent": now.Add(-10 * time.Minute).Unix(),
+			"old":    now.Add(-60 * 24 * time.Hour).Unix(),
+			"stale":  now.Add(-3 * 24 * time.Hour).Unix(),
+		},
+		Now: now,
+	}
+
+	assert.Equal(t, 8.0, frecency.Score("recent"))
+	assert.Equal(t, 1.25, frecency.Score("old"))
+	assert.Equal(t, 1.0, frecency.Score("stale"))
+	assert.Equal(t, 0.0, frecency.Score("unknown"))
+}
+
+func TestFrecencySearch(t *testing.T) {
+	now := time.Now()
+	frecency := Frecency{
+		UsageCount: map[string]int{"gamma": 3, "delta": 1},
+		LastUsed: map[string]int64{
+			"gamma": now.Unix(),
+			"delta": now.Unix(),
+		},
+		Now: now,
+	}
+	items := strToItems(&[]string{"alpha", "beta", "gamma", "delta", "epsilon"})
+	search := FrecencySearch(frecency, SearchMethods["fuzzy"])
+
+	t.Run("empty query follows frecency order", func(t *testing.T) {
+		res := search(context.Background(), items, "", false, 0)
+		assert.Equal(t, []string{"gamma", "delta", "alpha", "beta", "epsilon"}, itemsToStr(res))
+	})
+
+	t.Run("ties between matches follow frecency order", func(t *testing.T) {
+		// alpha is a prefix hit, the rest score the same
+		res := search(context.Background(), items, "a", false, 0)
+		assert.Equal(t, []string{"alpha", "gamma", "delta", "beta"}, itemsToStr(res))
+	})
+
+	t.Run("direct matches still beat fuzzy matches", func(t *testing.T) {
+		res := search(context.Background(), strToItems(&[]string{"delta", "alphadelt"}), "alp", false, 0)
+		assert.Equal(t, []string{"alphadelt"}, itemsToStr(res))
+	})
+
+	t.Run("preserve order skips ranking", func(t *testing.T) {
+		res := search(context.Background(), items, "", true, 0)
+		assert.Equal(t, []string{"alpha", "beta", "gamma", "delta", "epsilon"}, itemsToStr(res))
+	})
+}
+
+func TestSetupMenuUsesFrecency(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useFyneTestApp(t)
+
+	config := &model.Config{
+		MenuID:    "frecency-test",
+		MinWidth:  300,
+		MinHeight: 200,
+	}
+	gmenu, err := NewGMenu(SearchMethods["fuzzy"], config)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	cache := store.Cache{}
+	cache.RecordUsage("daily")
+	require.NoError(t, gmenu.store.SaveCache(cache))
+
+	require.NoError(t, gmenu.SetupMenu([]string{"first", "second", "daily"}, ""))
+	assert.Equal(t, []string{"daily", "first", "second"}, itemsToStr(gmenu.Search("")))
+}
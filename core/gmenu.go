@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,10 +12,10 @@ import (
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
-	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/frostbyte73/core"
 	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/pkg/config"
 	"github.com/hamidzr/gmenu/render"
 	"github.com/hamidzr/gmenu/store"
 	"github.com/sirupsen/logrus"
@@ -34,6 +35,10 @@ type GUI struct {
 	SearchEntry *render.SearchEntry
 	ItemsCanvas *render.ItemsCanvas
 	MenuLabel   *widget.Label
+	// Header shows the message and the header lines of the input.
+	Header *render.HeaderArea
+	// Preview is nil unless a preview command is configured.
+	Preview *render.PreviewPane
 }
 
 // GMenu is the main application struct for GoMenu.
@@ -46,16 +51,27 @@ type GMenu struct {
 	config     *model.Config
 	menuCancel context.CancelFunc
 	// menuMutex protects access to menu and menuCancel swapping
-	menuMutex     sync.RWMutex
+	menuMutex sync.RWMutex
+	// settingsMutex guards the settings Reconfigure replaces: config,
+	// searchMethod, preserveOrder, keyBindings, itemFormat and theme.
+	settingsMutex sync.RWMutex
 	app           fyne.App
 	store         store.Store
 	exitCode      model.ExitCode
 	dims          Dimensions
 	searchMethod  SearchMethod
 	preserveOrder bool
-	ui            *GUI
-	uiMutex       sync.Mutex
-	isRunning     bool
+	// keyBindings maps key chords to actions.
+	keyBindings keyBindings
+	// itemFormat splits structured input lines into fields.
+	itemFormat model.ItemFormat
+	// theme is the configured look of the GUI.
+	theme render.MainTheme
+	// previewer runs the preview command for the selected item.
+	previewer *Previewer
+	ui        *GUI
+	uiMutex   sync.Mutex
+	isRunning bool
 	// selectionFuse is a one-way switch that can only be broken once
 	selectionFuse core.Fuse
 	// selectionMutex guards selectionFuse operations and resets
@@ -73,6 +89,8 @@ type GMenu struct {
 	pendingQuit atomic.Bool
 	// quitScheduled ensures we only queue one quit operation.
 	quitScheduled atomic.Bool
+	// instanceLock is the lock claimed before the menu was built, if any.
+	instanceLock *InstanceLock
 }
 
 // Option configures behavior for GMenu instances during construction.
@@ -85,6 +103,47 @@ func WithManualVisibility() Option {
 	}
 }
 
+// WithInstanceLock makes RunAppForever use a lock taken with ClaimInstance
+// instead of claiming the menu ID itself. The caller still releases it.
+func WithInstanceLock(lock *InstanceLock) Option {
+	return func(g *GMenu) {
+		g.instanceLock = lock
+	}
+}
+
+// markKeyChord parses the mark key of a multi-select config.
+func markKeyChord(conf *model.Config) (keyChord, error) {
+	if !conf.Multi {
+		return keyChord{}, nil
+	}
+	markKey := conf.MarkKey
+	if markKey == "" {
+		markKey = model.DefaultConfig().MarkKey
+	}
+	chord, err := parseKeyChord(markKey)
+	if err != nil {
+		return keyChord{}, fmt.Errorf("invalid mark key: %w", err)
+	}
+	return chord, nil
+}
+
+// newTheme builds the GUI theme of conf on top of its named theme file.
+func newTheme(conf *model.Config) (render.MainTheme, error) {
+	themeConf := conf.Theme
+	if themeConf.Name != "" {
+		named, err := config.LoadTheme(themeConf.Name, conf.MenuID)
+		if err != nil {
+			return render.MainTheme{}, err
+		}
+		themeConf = named.Overlay(themeConf)
+	}
+	mainTheme, err := render.NewMainTheme(themeConf)
+	if err != nil {
+		return render.MainTheme{}, fmt.Errorf("invalid theme: %w", err)
+	}
+	return mainTheme, nil
+}
+
 // newAppFunc creates a new fyne App. Overridden in tests to use fyne test app.
 var newAppFunc = func() fyne.App { return app.New() }
 
@@ -108,7 +167,7 @@ func NewGMenuWithApp(
 	conf *model.Config,
 	opts ...Option,
 ) (*GMenu, error) {
-	store, err := store.NewFileStore[store.Cache, store.Config]([]string{"gmenu", conf.MenuID}, "yaml")
+	store, err := newMenuStore(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +191,29 @@ func NewGMenuWithApp(
 		// selectionFuse is initialized as zero value (ready to be broken)
 		isShown: false, // initially not shown
 	}
+	if g.itemFormat, err = model.NewItemFormat(conf.InputFormat, conf.Delimiter, conf.WithNth, conf.OutputNth, conf.GroupNth); err != nil {
+		return nil, err
+	}
+	if g.keyBindings, err = newKeyBindings(conf); err != nil {
+		return nil, err
+	}
+	if g.theme, err = newTheme(conf); err != nil {
+		return nil, err
+	}
+	if err := validateOnConflict(conf.OnConflict); err != nil {
+		return nil, err
+	}
+	if err := validateGroupOrder(conf.GroupOrder); err != nil {
+		return nil, err
+	}
+	switch conf.PreviewPosition {
+	case "", "right", "bottom":
+	default:
+		return nil, fmt.Errorf("invalid preview position %q: expected right or bottom", conf.PreviewPosition)
+	}
+	if conf.Preview != "" {
+		g.previewer = NewPreviewer(conf.Preview, PreviewDebounce, g.setPreviewText)
+	}
 	for _, opt := range opts {
 		opt(g)
 	}
@@ -146,41 +228,93 @@ func (g *GMenu) GetExitCode() model.ExitCode {
 	return g.exitCode
 }
 
-// HasSingleMatch reports whether the current filtered list has exactly one selectable item.
+// HasSingleMatch reports whether the query matches exactly one selectable
+// item. Matches cut off by max_results count too.
 func (g *GMenu) HasSingleMatch() bool {
 	g.menu.itemsMutex.Lock()
 	defer g.menu.itemsMutex.Unlock()
 
-	if len(g.menu.Filtered) != 1 {
-		return false
-	}
-	return g.menu.Filtered[0].Title != model.LoadingItem.Title
+	return !g.menu.placeholder && g.menu.MatchCount == 1 && len(g.menu.Filtered) == 1
 }
 
-// MatchCount returns the number of currently filtered items.
+// MatchCount returns the number of items matching the query, including the
+// ones max_results leaves out of the list.
 func (g *GMenu) MatchCount() int {
 	g.menu.itemsMutex.Lock()
 	defer g.menu.itemsMutex.Unlock()
-	return len(g.menu.Filtered)
+	return g.menu.MatchCount
 }
 
 // initValue computes the initial value for the search query
 func (g *GMenu) initValue(initialQuery string) (string, error) {
-	lastInput := ""
-	if g.menuID != "" && initialQuery == "" {
-		cache, err := g.store.LoadCache()
-		if err != nil {
-			logrus.Warn("Failed to load cache for initial value:", err)
-			// continue with empty lastInput rather than failing
-		} else if canBeHighlighted(cache.LastInput) {
-			lastInput = cache.LastInput
-		}
+	var st store.Store
+	if g.menuID != "" {
+		st = g.store
+	}
+	return initialInput(st, initialQuery), nil
+}
+
+// initialInput returns the query a menu starts with: initialQuery, or else
+// the last query saved in st when it can be highlighted. st may be nil.
+func initialInput(st store.Store, initialQuery string) string {
+	if initialQuery != "" || st == nil {
+		return initialQuery
+	}
+	cache, err := st.LoadCache()
+	if err != nil {
+		logrus.Warn("Failed to load cache for initial value:", err)
+		return ""
 	}
-	initValue := lastInput
-	if initialQuery != "" {
-		initValue = initialQuery
+	if canBeHighlighted(cache.LastInput) {
+		return cache.LastInput
 	}
-	return initValue, nil
+	return ""
+}
+
+// rankedSearchMethod wraps the search method with frecency ranking when the
+// menu has usage history to rank by.
+func (g *GMenu) rankedSearchMethod() SearchMethod {
+	g.settingsMutex.RLock()
+	searchMethod, preserveOrder := g.searchMethod, g.preserveOrder
+	g.settingsMutex.RUnlock()
+	var st store.Store
+	if g.menuID != "" {
+		st = g.store
+	}
+	return rankedSearch(st, searchMethod, preserveOrder)
+}
+
+// rankedSearch wraps searchMethod with frecency ranking by the usage saved in
+// st. st may be nil, which like preserveOrder leaves the order as it is.
+func rankedSearch(st store.Store, searchMethod SearchMethod, preserveOrder bool) SearchMethod {
+	if st == nil || preserveOrder {
+		return searchMethod
+	}
+	cache, err := st.LoadCache()
+	if err != nil {
+		logrus.Warn("Failed to load cache for frecency ranking:", err)
+		return searchMethod
+	}
+	frecency := NewFrecency(cache, time.Now())
+	if frecency.IsEmpty() {
+		return searchMethod
+	}
+	return FrecencySearch(frecency, searchMethod)
+}
+
+// conf returns the current config. Reconfigure replaces it rather than
+// modifying it, so callers may keep reading the returned value.
+func (g *GMenu) conf() *model.Config {
+	g.settingsMutex.RLock()
+	defer g.settingsMutex.RUnlock()
+	return g.config
+}
+
+// bindings returns the current key bindings.
+func (g *GMenu) bindings() keyBindings {
+	g.settingsMutex.RLock()
+	defer g.settingsMutex.RUnlock()
+	return g.keyBindings
 }
 
 // SetupMenu sets up the backing menu.
@@ -191,12 +325,20 @@ func (g *GMenu) SetupMenu(initialItems []string, initialQuery string) error {
 		cancel()
 		return fmt.Errorf("failed to get initial value: %w", err)
 	}
-	submenu, err := newMenu(ctx, initialItems, initVal, g.searchMethod, g.preserveOrder)
+	conf := g.conf()
+	g.settingsMutex.RLock()
+	preserveOrder, itemFormat := g.preserveOrder, g.itemFormat
+	g.settingsMutex.RUnlock()
+	submenu, err := newMenu(ctx, initialItems, initVal, groupedSearchMethod(conf, g.rankedSearchMethod()), preserveOrder, conf.IgnoreDiacritics, conf.MaxResults, itemFormat)
 	if err != nil {
 		cancel()
 		logrus.Error("Failed to setup menu:", err)
 		return fmt.Errorf("failed to create menu: %w", err)
 	}
+	if g.menuID != "" {
+		submenu.history = loadQueryHistory(g.store)
+	}
+	submenu.narrowing = narrowingSearchMethods[conf.SearchMethod]
 	// Cancel existing and swap under lock
 	g.menuMutex.Lock()
 	if g.menuCancel != nil {
@@ -221,28 +363,94 @@ func (g *GMenu) clearCache() error {
 }
 
 // ensureSelectionExitCode updates the exit code for a completed selection.
-// It preserves explicit cancellations but upgrades pending/optimistic states to success.
+// It preserves explicit cancellations but upgrades pending/optimistic states to
+// an accept.
 func (g *GMenu) ensureSelectionExitCode(code model.ExitCode) {
 	g.selectionMutex.Lock()
 	switch g.exitCode {
 	case model.Unset:
 		g.exitCode = code
 	case model.UserCanceled:
-		if code == model.NoError {
+		if code.IsAccept() {
 			g.exitCode = code
 		}
 	}
 	g.selectionMutex.Unlock()
 }
 
-func (g *GMenu) cacheState(value string) error {
-	return g.withCache(func(cache *store.Cache) error {
-		cache.SetLastInput(g.menu.query)
-		cache.SetLastEntry(value)
+// cacheState stores the query and the accepted values.
+func (g *GMenu) cacheState(values ...string) error {
+	if g.menuID == "" {
+		return nil // skip caching if menuID is not set
+	}
+	return recordSelection(g.store, g.menu.query, values)
+}
+
+// recordSelection saves an accepted selection in st: the query, and the
+// values for frecency ranking. The first value is remembered as the last
+// entry.
+func recordSelection(st store.Store, query string, values []string) error {
+	return st.UpdateCache(func(cache *store.Cache) error {
+		cache.SetLastInput(query)
+		cache.AddQuery(query)
+		if len(values) > 0 {
+			cache.SetLastEntry(values[0])
+		}
+		for _, value := range values {
+			cache.RecordUsage(value)
+		}
 		return nil
 	})
 }
 
+// ItemOutput returns the text printed for an accepted item.
+func (g *GMenu) ItemOutput(item model.MenuItem) string {
+	g.settingsMutex.RLock()
+	defer g.settingsMutex.RUnlock()
+	return g.itemFormat.Output(item)
+}
+
+// updatePreview requests a preview of the selected item.
+func (g *GMenu) updatePreview() {
+	if g.previewer == nil {
+		return
+	}
+	g.menuMutex.RLock()
+	m := g.menu
+	g.menuMutex.RUnlock()
+	if m == nil {
+		return
+	}
+	m.itemsMutex.Lock()
+	selected := m.selectedLocked()
+	entry := ""
+	if selected != nil {
+		entry = g.ItemOutput(*selected)
+	}
+	m.itemsMutex.Unlock()
+	g.previewer.Request(entry)
+}
+
+// setPreviewText shows preview command output in the preview pane.
+func (g *GMenu) setPreviewText(text string) {
+	g.safeUIUpdate(func() {
+		if g.ui != nil && g.ui.Preview != nil {
+			g.ui.Preview.SetText(text)
+		}
+	})
+}
+
+// isMarked reports whether an item is marked in the current menu.
+func (g *GMenu) isMarked(item model.MenuItem) bool {
+	if !g.conf().Multi {
+		return false
+	}
+	g.menuMutex.RLock()
+	m := g.menu
+	g.menuMutex.RUnlock()
+	return m != nil && m.isMarked(item)
+}
+
 func (g *GMenu) isUIInitialized() bool {
 	return g.ui != nil
 }
@@ -267,7 +475,7 @@ func (g *GMenu) initUI() error {
 			g.quitScheduled.Store(false)
 		})
 	}
-	g.app.Settings().SetTheme(render.MainTheme{Theme: theme.DefaultTheme()})
+	g.app.Settings().SetTheme(g.theme)
 
 	// g.app.Lifecycle().SetOnExitedForeground(func() {
 	// 	if g.ExitCode == constant.UnsetInt {
@@ -282,12 +490,8 @@ func (g *GMenu) initUI() error {
 		mainWindow = g.app.NewWindow(g.AppTitle)
 	}
 	mainWindow.SetTitle(g.AppTitle)
-	entryDisabledKeys := map[fyne.KeyName]bool{
-		fyne.KeyUp:   true,
-		fyne.KeyDown: true,
-		fyne.KeyTab:  true,
-	}
-	searchEntry := &render.SearchEntry{PropagationBlacklist: entryDisabledKeys}
+	// keys bound to actions don't reach the text entry
+	searchEntry := &render.SearchEntry{PropagationBlacklist: g.keyBindings.plainKeys()}
 	searchEntry.ExtendBaseWidget(searchEntry)
 	searchEntry.SetPlaceHolder(g.prompt)
 	searchEntry.OnFocusLost = func() {
@@ -323,12 +527,29 @@ func (g *GMenu) initUI() error {
 		}()
 	}
 	itemsCanvas := render.NewItemsCanvas()
+	itemsCanvas.IsMarked = g.isMarked
+	itemsCanvas.ShowScore = g.config.ShowScore
 	menuLabel := widget.NewLabel("menulabel")
 	inputBox := render.NewInputArea(searchEntry, menuLabel)
-	mainContainer := container.NewVBox(inputBox)
+	// the message and header lines sit between the input and the list
+	header := render.NewHeaderArea()
+	header.SetMessage(g.config.Message)
+	// the list fills the space below the input and scrolls through all results
+	var results fyne.CanvasObject = itemsCanvas.Container
+	var preview *render.PreviewPane
+	if g.previewer != nil {
+		preview = render.NewPreviewPane()
+		var split *container.Split
+		if g.config.PreviewPosition == "bottom" {
+			split = container.NewVSplit(itemsCanvas.Container, preview.Container)
+		} else {
+			split = container.NewHSplit(itemsCanvas.Container, preview.Container)
+		}
+		results = split
+	}
+	mainContainer := container.NewBorder(container.NewVBox(inputBox, header.Container), nil, nil, nil, results)
 	mainWindow.SetContent(mainContainer)
 	mainWindow.Resize(fyne.NewSize(g.dims.MinWidth, g.dims.MinHeight))
-	mainContainer.Add(itemsCanvas.Container)
 	mainWindow.Canvas().Focus(searchEntry)
 
 	// Add focus loss detection using OnClose
@@ -344,7 +565,9 @@ func (g *GMenu) initUI() error {
 		SearchEntry: searchEntry,
 		ItemsCanvas: itemsCanvas,
 		MenuLabel:   menuLabel,
+		Header:      header,
 		MainWindow:  mainWindow,
+		Preview:     preview,
 	}
 
 	return nil
@@ -370,6 +593,11 @@ func (g *GMenu) markSelectionMade() {
 func (g *GMenu) handleItemClick(index int) {
 	// Protect navigation state with menu items mutex
 	g.menu.itemsMutex.Lock()
+	if g.menu.placeholder {
+		// the loading placeholder can't be clicked
+		g.menu.itemsMutex.Unlock()
+		return
+	}
 	if index >= 0 && index < len(g.menu.Filtered) {
 		g.menu.Selected = index
 	}
@@ -446,21 +674,22 @@ func (g *GMenu) tryScheduleQuit() {
 	}()
 }
 
+// newMenuStore opens the store of the menu ID, inside the configured state
+// directory if there is one.
+func newMenuStore(conf *model.Config) (store.Store, error) {
+	stateDir := ""
+	if conf.StateDir != "" {
+		stateDir = filepath.Join(conf.StateDir, conf.MenuID)
+	}
+	return store.NewFileStoreIn[store.Cache, store.Config](stateDir, []string{"gmenu", conf.MenuID}, "yaml")
+}
+
 // withCache executes an operation on the cache and saves it back
 func (g *GMenu) withCache(operation func(*store.Cache) error) error {
 	if g.menuID == "" {
 		return nil // skip caching if menuID is not set
 	}
-	cache, err := g.store.LoadCache()
-	if err != nil {
-		return err
-	}
-
-	if err := operation(&cache); err != nil {
-		return err
-	}
-
-	return g.store.SaveCache(cache)
+	return g.store.UpdateCache(operation)
 }
 
 // setMenuBasedUI updates UI based on g.menu with minimal rerendering.
@@ -487,10 +716,11 @@ func (g *GMenu) setMenuBasedUI() error {
 		filtered := append([]model.MenuItem(nil), currentMenu.Filtered...)
 		selected := currentMenu.Selected
 		currentMenu.itemsMutex.Unlock()
-		g.ui.ItemsCanvas.Render(filtered, selected, g.config.NoNumericSelection, g.handleItemClick)
+		g.ui.ItemsCanvas.Render(filtered, selected, g.conf().NoNumericSelection, g.handleItemClick)
 		// show match items out of total item count.
 		g.ui.MenuLabel.SetText(g.matchCounterLabel())
 	})
+	g.updatePreview()
 	return nil
 }
 
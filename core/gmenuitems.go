@@ -16,8 +16,8 @@ func (g *GMenu) SetItems(items []string, serializables []model.GmenuSerializable
 		// avoid variable capture in loop by using index
 		menuItems = append(menuItems, model.MenuItem{AType: &serializables[i]})
 	}
-	g.menu.ItemsChan <- menuItems
-	if g.config.AutoAccept {
+	g.menu.setItems(menuItems)
+	if g.conf().AutoAccept {
 		go func() {
 			if g.AttemptAutoSelect() {
 				g.completeSelection()
@@ -26,25 +26,41 @@ func (g *GMenu) SetItems(items []string, serializables []model.GmenuSerializable
 	}
 }
 
-// addItems adds items to the menu.
+// addItems adds items to the menu. Items that are already listed are skipped.
 func (g *GMenu) addItems(items []string, tail bool) {
 	newMenuItems := g.menu.titlesToMenuItem(items)
-	g.menu.itemsMutex.Lock()
-	var newItems []model.MenuItem
 	if tail {
-		newItems = append(g.menu.items, newMenuItems...)
+		g.menu.appendItems(newMenuItems)
 	} else {
-		newItems = append(newMenuItems, g.menu.items...)
+		g.menu.prependItems(newMenuItems)
+	}
+}
+
+// SetHeaderLines shows lines above the list as fixed headers. They are
+// neither searched nor selectable.
+func (g *GMenu) SetHeaderLines(lines []string) {
+	g.safeUIUpdate(func() {
+		if g.ui != nil && g.ui.Header != nil {
+			g.ui.Header.SetHeaderLines(lines)
+		}
+	})
+}
+
+// SetLoading marks the menu as still receiving items. The match counter
+// shows it until loading is set back to false.
+func (g *GMenu) SetLoading(loading bool) {
+	g.menuMutex.RLock()
+	m := g.menu
+	g.menuMutex.RUnlock()
+	if m != nil {
+		m.setLoading(loading)
 	}
-	g.menu.itemsMutex.Unlock()
-	// TODO: add using SetItems?
-	g.menu.ItemsChan <- newItems
 }
 
 // AttemptAutoSelect attempts to auto select if conditions are met.
 // It returns true when a selection was made.
 func (g *GMenu) AttemptAutoSelect() bool {
-	if !g.config.AutoAccept {
+	if !g.conf().AutoAccept {
 		return false
 	}
 
@@ -87,14 +103,10 @@ func (g *GMenu) tryAutoSelectNow() bool {
 	return false
 }
 
-// shouldAutoSelect checks if auto-selection conditions are met
+// shouldAutoSelect checks if auto-selection conditions are met: the query
+// has to match exactly one item that isn't the loading placeholder.
 func (g *GMenu) shouldAutoSelect() bool {
-	g.menu.itemsMutex.Lock()
-	defer g.menu.itemsMutex.Unlock()
-
-	// need exactly one filtered item that isn't the loading placeholder
-	return len(g.menu.Filtered) == 1 &&
-		g.menu.Filtered[0].Title != model.LoadingItem.Title
+	return g.HasSingleMatch()
 }
 
 // PrependItems adds items to the beginning of the menu.
@@ -104,17 +116,15 @@ func (g *GMenu) PrependItems(items []string) {
 
 // AppendItems adds items to the end of the menu.
 func (g *GMenu) AppendItems(items []string) {
-	// fmt.Println("appending len items", len(items))
 	g.addItems(items, true)
 }
 
-// selectedItem returns the selected item if in bound or nil.
+// selectedItem returns the selected item, or nil when nothing or only the
+// loading placeholder is listed.
 func (g *GMenu) selectedItem() *model.MenuItem {
-	if g.menu.Selected >= 0 && g.menu.Selected < len(g.menu.Filtered) {
-		selected := g.menu.Filtered[g.menu.Selected]
-		return &selected
-	}
-	return nil
+	g.menu.itemsMutex.Lock()
+	defer g.menu.itemsMutex.Unlock()
+	return g.menu.selectedLocked()
 }
 
 // SelectedValue returns the selected item.
@@ -123,15 +133,37 @@ func (g *GMenu) SelectedValue() (*model.MenuItem, error) {
 	g.WaitForSelection()
 	if g.exitCode == model.Unset {
 		// this is a valid case in daemon mode.
-	} else if g.exitCode != model.NoError {
+	} else if !g.exitCode.IsAccept() {
 		return nil, errors.Wrap(g.exitCode, "an error code is set")
 	}
 	// TODO: cli option for allowing query.
 	if selected := g.selectedItem(); selected != nil {
 		return selected, nil
 	}
-	if g.config.AcceptCustomSelection {
+	if g.conf().AcceptCustomSelection {
 		return &model.MenuItem{Title: g.menu.query}, nil
 	}
 	return nil, model.ErrCustomUserEntry
 }
+
+// Query returns the current search query.
+func (g *GMenu) Query() string {
+	g.menu.queryMutex.Lock()
+	defer g.menu.queryMutex.Unlock()
+	return g.menu.query
+}
+
+// SelectedValues returns the marked items in input order in multi-select mode.
+// When nothing is marked it falls back to the single selected value.
+func (g *GMenu) SelectedValues() ([]model.MenuItem, error) {
+	selected, err := g.SelectedValue()
+	if err != nil {
+		return nil, err
+	}
+	if g.conf().Multi {
+		if marked := g.menu.markedItems(); len(marked) > 0 {
+			return marked, nil
+		}
+	}
+	return []model.MenuItem{*selected}, nil
+}
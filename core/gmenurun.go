@@ -1,7 +1,9 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 
 	"github.com/frostbyte73/core"
 	"github.com/hamidzr/gmenu/model"
@@ -45,6 +47,9 @@ func (g *GMenu) Quit() {
 
 	// Ensure UI is hidden before quitting (in case it wasn't already)
 	g.HideUI()
+	if g.previewer != nil {
+		g.previewer.Stop()
+	}
 
 	// Safely quit the Fyne app with error recovery
 	func() {
@@ -55,8 +60,6 @@ func (g *GMenu) Quit() {
 		}()
 		g.requestQuit()
 	}()
-
-	_ = RemovePidFile(g.menuID)
 }
 
 // Reset resets the gmenu state without exiting.
@@ -75,6 +78,9 @@ func (g *GMenu) Reset(resetInput bool) {
 	m := g.menu
 	g.menuMutex.RUnlock()
 
+	if m != nil {
+		m.clearMarks()
+	}
 	if resetInput && m != nil {
 		// reset query through safe paths to avoid data races
 		m.queryMutex.Lock()
@@ -111,14 +117,82 @@ func (g *GMenu) Reset(resetInput bool) {
 		filtered := append([]model.MenuItem(nil), m.Filtered...)
 		selected := m.Selected
 		m.itemsMutex.Unlock()
-		g.ui.ItemsCanvas.Render(filtered, selected, g.config.NoNumericSelection, g.handleItemClick)
+		g.ui.ItemsCanvas.Render(filtered, selected, g.conf().NoNumericSelection, g.handleItemClick)
 		g.ui.MenuLabel.SetText(g.matchCounterLabel())
 	}
 	g.uiMutex.Unlock()
+	g.updatePreview()
 
 	logrus.Info("done resetting gmenu state")
 }
 
+// Reconfigure applies conf to the menu so a resident process can serve menus
+// with different settings. The menu ID and state directory hold the instance
+// lock and history, and the preview and window size are part of the built UI,
+// so a conf that changes any of them is rejected.
+func (g *GMenu) Reconfigure(conf *model.Config) error {
+	current := g.conf()
+	if conf.MenuID != current.MenuID || conf.StateDir != current.StateDir {
+		return fmt.Errorf("menu_id and state_dir cannot change in a running menu (%q in %q)", current.MenuID, current.StateDir)
+	}
+	if conf.Preview != current.Preview || conf.PreviewPosition != current.PreviewPosition {
+		return errors.New("preview and preview_position cannot change in a running menu")
+	}
+	if conf.MinWidth != current.MinWidth || conf.MinHeight != current.MinHeight ||
+		conf.MaxWidth != current.MaxWidth || conf.MaxHeight != current.MaxHeight {
+		return errors.New("the window size cannot change in a running menu")
+	}
+	searchMethod, ok := SearchMethods[conf.SearchMethod]
+	if !ok {
+		return fmt.Errorf("invalid search method: %s", conf.SearchMethod)
+	}
+	itemFormat, err := model.NewItemFormat(conf.InputFormat, conf.Delimiter, conf.WithNth, conf.OutputNth, conf.GroupNth)
+	if err != nil {
+		return err
+	}
+	bindings, err := newKeyBindings(conf)
+	if err != nil {
+		return err
+	}
+	mainTheme, err := newTheme(conf)
+	if err != nil {
+		return err
+	}
+	if err := validateOnConflict(conf.OnConflict); err != nil {
+		return err
+	}
+	if err := validateGroupOrder(conf.GroupOrder); err != nil {
+		return err
+	}
+
+	// setting a theme refreshes every widget, so only do it on a change
+	themeChanged := !reflect.DeepEqual(conf.Theme, current.Theme)
+	updated := *conf
+	g.settingsMutex.Lock()
+	g.config = &updated
+	g.AppTitle = conf.Title
+	g.prompt = conf.Prompt
+	g.searchMethod = searchMethod
+	g.preserveOrder = conf.PreserveOrder
+	g.itemFormat = itemFormat
+	g.keyBindings = bindings
+	g.theme = mainTheme
+	g.settingsMutex.Unlock()
+	g.safeUIUpdate(func() {
+		if themeChanged {
+			g.app.Settings().SetTheme(mainTheme)
+		}
+		if g.ui != nil {
+			g.ui.MainWindow.SetTitle(conf.Title)
+			g.ui.SearchEntry.SetPlaceHolder(conf.Prompt)
+			g.ui.SearchEntry.PropagationBlacklist = bindings.plainKeys()
+			g.ui.Header.SetMessage(conf.Message)
+			g.ui.ItemsCanvas.ShowScore = conf.ShowScore
+		}
+	})
+	return nil
+}
+
 func (g *GMenu) RunAppForever() error {
 	if g.isRunning {
 		panic("Run called multiple times")
@@ -129,16 +203,23 @@ func (g *GMenu) RunAppForever() error {
 	}
 	g.isRunning = true
 
-	// create PID file when app starts running
-	_, err := createPidFile(g.menuID)
+	// hold the instance lock while the app runs
+	if g.instanceLock == nil {
+		lock, err := ClaimInstance(g.conf())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Release(); err != nil {
+				logrus.Errorf("failed to release instance lock: %v", err)
+			}
+		}()
+	}
+	stopControl, err := g.serveControl()
 	if err != nil {
-		return fmt.Errorf("failed to create PID file: %w", err)
+		return err
 	}
-	defer func() {
-		if err := RemovePidFile(g.menuID); err != nil {
-			logrus.Errorf("failed to remove PID file: %v", err)
-		}
-	}()
+	defer stopControl()
 
 	g.app.Run()
 	return nil
@@ -183,14 +264,18 @@ func (g *GMenu) completeSelection() {
 
 // Run starts the application.
 func (g *GMenu) CacheSelectedValue() error {
-	selectedVal, err := g.SelectedValue()
+	selectedVals, err := g.SelectedValues()
 	if err != nil {
 		if cacheErr := g.clearCache(); cacheErr != nil {
 			fmt.Println("Failed to clear cache:", cacheErr)
 		}
 		return err
 	}
-	err = g.cacheState(selectedVal.ComputedTitle())
+	values := make([]string, 0, len(selectedVals))
+	for _, val := range selectedVals {
+		values = append(values, val.Key())
+	}
+	err = g.cacheState(values...)
 	return err
 }
 
@@ -11,9 +11,27 @@ func (g *GMenu) matchCounterLabel() string {
 	if m == nil {
 		return "[0/0]"
 	}
-	m.itemsMutex.Lock()
-	matchCount := m.MatchCount
-	total := len(m.items)
-	m.itemsMutex.Unlock()
-	return fmt.Sprintf("[%d/%d]", matchCount, total)
+	return m.counterLabel()
+}
+
+// counterLabel returns the match counter, preceded by the pattern of the
+// history search while one is going on.
+func (m *menu) counterLabel() string {
+	counter := formatMatchCounter(m.counts())
+	m.queryMutex.Lock()
+	query := m.query
+	m.queryMutex.Unlock()
+	if pattern, ok := m.history.searchPattern(query); ok {
+		return fmt.Sprintf("history %q %s", pattern, counter)
+	}
+	return counter
+}
+
+// formatMatchCounter formats the match counter. A trailing ellipsis shows
+// that items are still loading.
+func formatMatchCounter(matches, total int, loading bool) string {
+	if loading {
+		return fmt.Sprintf("[%d/%d…]", matches, total)
+	}
+	return fmt.Sprintf("[%d/%d]", matches, total)
 }
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hamidzr/gmenu/model"
+)
+
+// validateGroupOrder checks the group_order setting.
+func validateGroupOrder(order string) error {
+	switch order {
+	case "", model.GroupOrderTogether, model.GroupOrderInterleave:
+		return nil
+	}
+	return fmt.Errorf("invalid group_order %q: expected %s or %s", order,
+		model.GroupOrderTogether, model.GroupOrderInterleave)
+}
+
+// groupedSearchMethod wraps searchMethod to keep groups together unless conf
+// interleaves them.
+func groupedSearchMethod(conf *model.Config, searchMethod SearchMethod) SearchMethod {
+	if conf.GroupOrder == model.GroupOrderInterleave {
+		return searchMethod
+	}
+	return GroupSearch(searchMethod)
+}
+
+// GroupSearch wraps a search method so that the matches of each group are
+// listed together. Groups are ordered by their best match and their matches
+// keep the order of the wrapped method.
+func GroupSearch(searchMethod SearchMethod) SearchMethod {
+	return func(ctx context.Context, items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
+		return applyLimit(groupTogether(searchMethod(ctx, items, query, preserveOrder, 0)), limit)
+	}
+}
+
+// groupTogether stably moves the items of each group next to the first item
+// of the group.
+func groupTogether(items []model.MenuItem) []model.MenuItem {
+	mixed := false
+	for i := 1; i < len(items); i++ {
+		if items[i].Group != items[0].Group {
+			mixed = true
+			break
+		}
+	}
+	if !mixed {
+		return items
+	}
+	index := make(map[string]int)
+	var groups [][]model.MenuItem
+	for _, item := range items {
+		i, ok := index[item.Group]
+		if !ok {
+			i = len(groups)
+			index[item.Group] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	grouped := make([]model.MenuItem, 0, len(items))
+	for _, group := range groups {
+		grouped = append(grouped, group...)
+	}
+	return grouped
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func groupedItems() []model.MenuItem {
+	return []model.MenuItem{
+		{Title: "main.go", Group: "alacritty"},
+		{Title: "Spotify Premium", Group: "spotify"},
+		{Title: "today.md", Group: "alacritty"},
+		{Title: "notes"},
+		{Title: "main page", Group: "firefox"},
+	}
+}
+
+func TestGroupSearch(t *testing.T) {
+	t.Run("keeps groups together in order of their best match", func(t *testing.T) {
+		res := GroupSearch(DirectSearch)(context.Background(), groupedItems(), "", false, 0)
+		assert.Equal(t, []string{"main.go", "today.md", "Spotify Premium", "notes", "main page"}, itemsToStr(res))
+	})
+
+	t.Run("groups the matches", func(t *testing.T) {
+		res := GroupSearch(SearchMethods["fuzzy"])(context.Background(), groupedItems(), "ma", false, 0)
+		assert.Equal(t, []string{"main.go", "main page"}, itemsToStr(res))
+	})
+
+	t.Run("limit applies after grouping", func(t *testing.T) {
+		res := GroupSearch(DirectSearch)(context.Background(), groupedItems(), "", false, 2)
+		assert.Equal(t, []string{"main.go", "today.md"}, itemsToStr(res))
+	})
+
+	t.Run("interleave keeps the search order", func(t *testing.T) {
+		method := groupedSearchMethod(&model.Config{GroupOrder: model.GroupOrderInterleave}, DirectSearch)
+		res := method(context.Background(), groupedItems(), "", false, 0)
+		assert.Equal(t, itemsToStr(groupedItems()), itemsToStr(res))
+	})
+
+	t.Run("validates the order", func(t *testing.T) {
+		assert.NoError(t, validateGroupOrder(""))
+		assert.NoError(t, validateGroupOrder(model.GroupOrderTogether))
+		assert.Error(t, validateGroupOrder("by-score"))
+	})
+}
+
+func TestTerminalMenuGroups(t *testing.T) {
+	cfg := &model.Config{NoNumericSelection: true, SearchMethod: "direct", GroupNth: "2", WithNth: "3.."}
+	menu := newTestTerminalMenu(t, cfg,
+		"2 [alacritty] main.go (.)",
+		"8 [spotify] Spotify Premium",
+		"2 [alacritty] today.md (.)",
+	)
+
+	var frame bytes.Buffer
+	menu.render(&frame, 40, 6)
+	out := frame.String()
+	assert.Contains(t, out, ansiDim+"[alacritty]"+ansiReset)
+	assert.Contains(t, out, ansiDim+"[spotify]"+ansiReset)
+	assert.Less(t, bytes.Index(frame.Bytes(), []byte("today.md")), bytes.Index(frame.Bytes(), []byte("[spotify]")))
+
+	// down skips the header between the groups
+	menu.handleInput([]byte("\x1b[B\x1b[B"), 5)
+	require.Len(t, menu.Selection(), 1)
+	assert.Equal(t, "Spotify Premium", menu.Selection()[0].Title)
+	assert.Equal(t, "8 [spotify] Spotify Premium", menu.ItemOutput(menu.Selection()[0]))
+}
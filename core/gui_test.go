@@ -1,12 +1,17 @@
 package core
 
 import (
+	"image/color"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
 	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/render"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -57,6 +62,27 @@ func TestGUIComponentInitialization(t *testing.T) {
 	assert.Contains(t, []string{"menulabel", config.Prompt}, gmenu.ui.MenuLabel.Text)
 }
 
+func TestGUIHeaderLines(t *testing.T) {
+	app := test.NewApp()
+	defer app.Quit()
+
+	config := model.DefaultConfig()
+	config.Message = "pick a pod"
+	gmenu, err := NewGMenuWithApp(app, SearchMethods["fuzzy"], config)
+	require.NoError(t, err)
+	gmenu.SetHeaderLines([]string{"NAME READY"})
+	require.NoError(t, gmenu.SetupMenu([]string{"web 1/1", "db 1/1"}, ""))
+
+	assert.Equal(t, "pick a pod", gmenu.ui.Header.Message())
+	assert.Equal(t, "NAME READY", gmenu.ui.Header.HeaderLines())
+	assert.Equal(t, 2, gmenu.MatchCount(), "header lines aren't items")
+
+	updated := *config
+	updated.Message = ""
+	require.NoError(t, gmenu.Reconfigure(&updated))
+	assert.Equal(t, "", gmenu.ui.Header.Message())
+}
+
 // TestSearchEntryInteraction tests user interaction with the search entry
 func TestSearchEntryInteraction(t *testing.T) {
 	app := test.NewApp()
@@ -397,3 +423,31 @@ func TestInitialQueryHandling(t *testing.T) {
 	// Search entry should have the initial query
 	assert.Equal(t, config.InitialQuery, gmenu.ui.SearchEntry.Text)
 }
+
+// TestTheme tests that the configured theme, including named theme files, is applied
+func TestTheme(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	themeDir := filepath.Join(tmpDir, ".config", "gmenu", "themes")
+	require.NoError(t, os.MkdirAll(themeDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(themeDir, "night.yaml"), []byte("variant: dark\ndark:\n  background: \"#101010\"\n  match: \"#ff0000\"\n"), 0o644))
+
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{
+		Theme: model.Theme{Name: "night", Dark: model.ThemeColors{Match: "#00ff00"}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	applied := gmenu.app.Settings().Theme()
+	assert.Equal(t, color.NRGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xff}, applied.Color(theme.ColorNameBackground, theme.VariantLight))
+	assert.Equal(t, color.NRGBA{G: 0xff, A: 0xff}, applied.Color(render.ColorNameMatch, theme.VariantDark))
+
+	_, err = NewGMenu(DirectSearch, &model.Config{Theme: model.Theme{Name: "missing"}})
+	assert.Error(t, err)
+}
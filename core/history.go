@@ -0,0 +1,112 @@
+package core
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/hamidzr/gmenu/store"
+)
+
+// queryHistory walks the queries of earlier selections of a menu ID, like a
+// shell walks its command history. Both front ends share it.
+type queryHistory struct {
+	mu sync.Mutex
+	// entries holds the queries, oldest first.
+	entries []string
+	// pos is the entry shown while walking the history, len(entries) when
+	// the query is the user's own.
+	pos int
+	// draft is the query typed before walking the history.
+	draft string
+	// pattern is what the reverse search looks for, and result is the
+	// query it last found. The search ends once the query is edited.
+	pattern string
+	result  string
+}
+
+// newQueryHistory returns a history over entries, oldest first.
+func newQueryHistory(entries []string) *queryHistory {
+	return &queryHistory{
+		entries: append([]string(nil), entries...),
+		pos:     len(entries),
+	}
+}
+
+// loadQueryHistory reads the query history of the menu ID from st. A menu
+// without a store gets an empty history.
+func loadQueryHistory(st store.Store) *queryHistory {
+	if st == nil {
+		return newQueryHistory(nil)
+	}
+	cache, err := st.LoadCache()
+	if err != nil {
+		return newQueryHistory(nil)
+	}
+	return newQueryHistory(cache.QueryHistory)
+}
+
+// prev returns the query before the shown one. query is the current query,
+// which is kept as the draft when leaving it.
+func (h *queryHistory) prev(query string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pattern, h.result = "", ""
+	if h.pos == 0 {
+		return "", false
+	}
+	if h.pos == len(h.entries) {
+		h.draft = query
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// next returns the query after the shown one, or the draft past the newest.
+func (h *queryHistory) next() (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pattern, h.result = "", ""
+	if h.pos == len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.pos], true
+}
+
+// search returns the newest query containing the current query, ignoring
+// case. Searching again while the query is the last result continues with
+// older matches of the same pattern.
+func (h *queryHistory) search(query string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	start := len(h.entries) - 1
+	if h.result != "" && query == h.result {
+		start = h.pos - 1
+	} else {
+		h.pattern, h.result = query, ""
+		h.draft = query
+	}
+	pattern := strings.ToLower(h.pattern)
+	for i := start; i >= 0; i-- {
+		if h.entries[i] != query && strings.Contains(strings.ToLower(h.entries[i]), pattern) {
+			h.pos = i
+			h.result = h.entries[i]
+			return h.result, true
+		}
+	}
+	return "", false
+}
+
+// searchPattern returns the pattern of the reverse search that found query,
+// if the user is still in it.
+func (h *queryHistory) searchPattern(query string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.result == "" || query != h.result {
+		return "", false
+	}
+	return h.pattern, true
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHistory(t *testing.T) {
+	t.Run("prev and next", func(t *testing.T) {
+		h := newQueryHistory([]string{"alpha", "beta", "gamma"})
+		_, ok := h.next()
+		assert.False(t, ok, "nothing after the draft")
+
+		query, ok := h.prev("draft")
+		assert.True(t, ok)
+		assert.Equal(t, "gamma", query)
+		query, _ = h.prev(query)
+		assert.Equal(t, "beta", query)
+		query, _ = h.prev(query)
+		assert.Equal(t, "alpha", query)
+		_, ok = h.prev(query)
+		assert.False(t, ok, "nothing before the oldest query")
+
+		query, _ = h.next()
+		assert.Equal(t, "beta", query)
+		query, _ = h.next()
+		assert.Equal(t, "gamma", query)
+		query, _ = h.next()
+		assert.Equal(t, "draft", query, "the typed query comes back past the newest")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		h := newQueryHistory(nil)
+		_, ok := h.prev("query")
+		assert.False(t, ok)
+		_, ok = h.search("query")
+		assert.False(t, ok)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		h := newQueryHistory([]string{"git log", "ls docs", "git status", "make"})
+		query, ok := h.search("GIT")
+		assert.True(t, ok)
+		assert.Equal(t, "git status", query)
+		pattern, ok := h.searchPattern(query)
+		assert.True(t, ok)
+		assert.Equal(t, "GIT", pattern)
+
+		// searching again continues with older matches
+		query, ok = h.search(query)
+		assert.True(t, ok)
+		assert.Equal(t, "git log", query)
+		_, ok = h.search(query)
+		assert.False(t, ok)
+
+		// editing the query starts a new search
+		_, ok = h.searchPattern("git lo")
+		assert.False(t, ok)
+		query, ok = h.search("doc")
+		assert.True(t, ok)
+		assert.Equal(t, "ls docs", query)
+
+		// walking the history continues from the match
+		query, _ = h.prev(query)
+		assert.Equal(t, "git log", query)
+	})
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"github.com/hamidzr/gmenu/model"
+)
+
+// defaultKeyBindings are the bindings both front ends start from. Chords
+// in the keybindings config are added on top of them.
+var defaultKeyBindings = map[string]model.KeyAction{
+	"up":       model.ActionUp,
+	"ctrl+p":   model.ActionUp,
+	"down":     model.ActionDown,
+	"tab":      model.ActionDown,
+	"ctrl+n":   model.ActionDown,
+	"pageup":   model.ActionPageUp,
+	"pagedown": model.ActionPageDown,
+	"home":     model.ActionFirst,
+	"end":      model.ActionLast,
+	"return":   model.ActionAccept,
+	"escape":   model.ActionCancel,
+	"ctrl+l":   model.ActionClearQuery,
+	"alt+p":    model.ActionHistoryPrev,
+	"alt+n":    model.ActionHistoryNext,
+	"ctrl+r":   model.ActionHistorySearch,
+}
+
+// keyBindings maps key chords to the actions they trigger.
+type keyBindings map[keyChord]model.KeyAction
+
+// newKeyBindings builds the bindings for conf: the defaults, the mark key in
+// multi-select mode and the configured keybindings, in increasing priority.
+func newKeyBindings(conf *model.Config) (keyBindings, error) {
+	bindings := make(keyBindings, len(defaultKeyBindings)+len(conf.Keybindings)+1)
+	for chord, action := range defaultKeyBindings {
+		parsed, err := parseKeyChord(chord)
+		if err != nil {
+			return nil, err
+		}
+		bindings[parsed] = action
+	}
+	if conf.Multi {
+		markKey, err := markKeyChord(conf)
+		if err != nil {
+			return nil, err
+		}
+		bindings[markKey] = model.ActionToggleMark
+	}
+	for chord, name := range conf.Keybindings {
+		parsed, err := parseKeyChord(chord)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keybinding: %w", err)
+		}
+		action, err := model.ParseKeyAction(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keybinding for %q: %w", chord, err)
+		}
+		bindings[parsed] = action
+	}
+	return bindings, nil
+}
+
+// lookup returns the action bound to chord. The keypad enter key acts like
+// return.
+func (b keyBindings) lookup(chord keyChord) (model.KeyAction, bool) {
+	if chord.name == fyne.KeyEnter {
+		chord.name = fyne.KeyReturn
+	}
+	action, ok := b[chord]
+	if !ok || action == model.ActionNone {
+		return "", false
+	}
+	return action, true
+}
+
+// plainKeys returns the keys bound without modifiers. The search entry
+// doesn't get to handle them as text edits.
+func (b keyBindings) plainKeys() map[fyne.KeyName]bool {
+	keys := make(map[fyne.KeyName]bool)
+	for chord, action := range b {
+		if chord.modifier == 0 && action != model.ActionNone {
+			keys[chord.name] = true
+		}
+	}
+	return keys
+}
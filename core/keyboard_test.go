@@ -1,12 +1,16 @@
 package core
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/test"
 	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -430,3 +434,205 @@ func TestSpecialKeys(t *testing.T) {
 		})
 	}
 }
+
+// TestPagingKeys tests that paging keys reach every match, not just the first page
+func TestPagingKeys(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{
+		Title:     "Paging Test",
+		Prompt:    "Search",
+		MinWidth:  300,
+		MinHeight: 200,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	items := make([]string, 500)
+	for i := range items {
+		items[i] = fmt.Sprintf("file-%03d", i)
+	}
+	require.NoError(t, gmenu.SetupMenu(items, ""))
+	require.Len(t, gmenu.menu.Filtered, len(items))
+
+	pageSize := gmenu.ui.ItemsCanvas.PageSize()
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyPageDown})
+	assert.Equal(t, pageSize, gmenu.menu.Selected)
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnd})
+	assert.Equal(t, len(items)-1, gmenu.menu.Selected)
+
+	// paging stops at the last item instead of wrapping
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyPageDown})
+	assert.Equal(t, len(items)-1, gmenu.menu.Selected)
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyPageUp})
+	assert.Equal(t, len(items)-1-pageSize, gmenu.menu.Selected)
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	assert.Equal(t, 0, gmenu.menu.Selected)
+}
+
+// TestMaxResults tests that max_results caps the listed matches but not the match count
+func TestMaxResults(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{MaxResults: 3})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	require.NoError(t, gmenu.SetupMenu([]string{"a1", "a2", "a3", "a4", "a5"}, ""))
+	assert.Len(t, gmenu.menu.Filtered, 3)
+	assert.Equal(t, 5, gmenu.menu.MatchCount)
+	assert.Equal(t, "[5/5]", gmenu.matchCounterLabel())
+	assert.Equal(t, 5, gmenu.MatchCount())
+}
+
+// TestMaxResultsAutoAccept tests that auto-accept counts the matches
+// max_results leaves out of the list
+func TestMaxResultsAutoAccept(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{MaxResults: 1, AutoAccept: true})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	require.NoError(t, gmenu.SetupMenu([]string{"apple", "apricot", "banana"}, "ap"))
+	assert.Len(t, gmenu.menu.Filtered, 1)
+	assert.Equal(t, 2, gmenu.MatchCount())
+	assert.False(t, gmenu.HasSingleMatch())
+	assert.False(t, gmenu.shouldAutoSelect(), "two matches must not auto-accept")
+
+	gmenu.menu.Search("apr")
+	assert.Equal(t, 1, gmenu.MatchCount())
+	assert.True(t, gmenu.shouldAutoSelect())
+}
+
+// TestKeybindings tests that configured keybindings drive the GUI
+func TestKeybindings(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{
+		NoNumericSelection: true,
+		Keybindings:        map[string]string{"ctrl+j": "down", "ctrl+k": "up", "home": "none", "ctrl+g": "cancel"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NoError(t, gmenu.SetupMenu([]string{"one", "two", "three"}, ""))
+	entry := gmenu.ui.SearchEntry
+
+	entry.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyJ, Modifier: fyne.KeyModifierControl})
+	entry.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyJ, Modifier: fyne.KeyModifierControl})
+	assert.Equal(t, 2, gmenu.menu.Selected)
+	entry.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierControl})
+	assert.Equal(t, 1, gmenu.menu.Selected)
+
+	// default bindings stay unless they are unbound
+	entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnd})
+	assert.Equal(t, 2, gmenu.menu.Selected)
+	entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	assert.Equal(t, 2, gmenu.menu.Selected)
+	assert.False(t, entry.PropagationBlacklist[fyne.KeyHome], "unbound keys reach the entry")
+
+	entry.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: fyne.KeyModifierControl})
+	gmenu.WaitForSelection()
+	assert.Equal(t, model.UserCanceled, gmenu.GetExitCode())
+}
+
+// TestCustomAcceptKeys tests that custom accept keys select with their own exit code
+func TestCustomAcceptKeys(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{
+		NoNumericSelection: true,
+		Keybindings:        map[string]string{"alt+2": "custom-2"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NoError(t, gmenu.SetupMenu([]string{"one", "two"}, ""))
+
+	gmenu.ui.SearchEntry.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.Key2, Modifier: fyne.KeyModifierAlt})
+	gmenu.WaitForSelection()
+	assert.Equal(t, model.CustomAcceptCode(2), gmenu.GetExitCode())
+	vals, err := gmenu.SelectedValues()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one"}, itemsToStr(vals))
+}
+
+// TestQueryHistoryKeys tests walking and searching the query history
+func TestQueryHistoryKeys(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{
+		MenuID:             "history-keys-test",
+		StateDir:           t.TempDir(),
+		NoNumericSelection: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NoError(t, gmenu.store.UpdateCache(func(cache *store.Cache) error {
+		cache.AddQuery("one")
+		cache.AddQuery("two")
+		return nil
+	}))
+	require.NoError(t, gmenu.SetupMenu([]string{"one", "two", "three"}, ""))
+	entry := gmenu.ui.SearchEntry
+	altP := &desktop.CustomShortcut{KeyName: fyne.KeyP, Modifier: fyne.KeyModifierAlt}
+	altN := &desktop.CustomShortcut{KeyName: fyne.KeyN, Modifier: fyne.KeyModifierAlt}
+	ctrlR := &desktop.CustomShortcut{KeyName: fyne.KeyR, Modifier: fyne.KeyModifierControl}
+
+	entry.SetText("th")
+	entry.TypedShortcut(altP)
+	assert.Equal(t, "two", entry.Text)
+	entry.TypedShortcut(altP)
+	assert.Equal(t, "one", entry.Text)
+	entry.TypedShortcut(altN)
+	entry.TypedShortcut(altN)
+	assert.Equal(t, "th", entry.Text)
+
+	entry.SetText("o")
+	entry.TypedShortcut(ctrlR)
+	assert.Equal(t, "two", entry.Text)
+	entry.TypedShortcut(ctrlR)
+	assert.Equal(t, "one", entry.Text)
+	assert.Eventually(t, func() bool {
+		return strings.HasPrefix(gmenu.matchCounterLabel(), `history "o"`)
+	}, time.Second, 10*time.Millisecond)
+
+	// accepted queries are added to the history
+	entry.SetText("thr")
+	assert.Eventually(t, func() bool { return gmenu.menu.counterLabel() == "[1/3]" }, time.Second, 10*time.Millisecond)
+	entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	gmenu.WaitForSelection()
+	require.NoError(t, gmenu.CacheSelectedValue())
+	cache, err := gmenu.store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "thr"}, cache.QueryHistory)
+}
+
+// TestInvalidKeybindings tests that bad chords and actions are rejected
+func TestInvalidKeybindings(t *testing.T) {
+	useFyneTestApp(t)
+	_, err := NewGMenu(DirectSearch, &model.Config{Keybindings: map[string]string{"hyper+j": "down"}})
+	assert.Error(t, err)
+	_, err = NewGMenu(DirectSearch, &model.Config{Keybindings: map[string]string{"ctrl+j": "jump"}})
+	assert.Error(t, err)
+}
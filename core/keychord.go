@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// keyChord is a key combined with optional modifiers, e.g. "shift+tab" or "ctrl+space".
+type keyChord struct {
+	name     fyne.KeyName
+	modifier fyne.KeyModifier
+}
+
+var chordModifiers = map[string]fyne.KeyModifier{
+	"shift":   fyne.KeyModifierShift,
+	"ctrl":    fyne.KeyModifierControl,
+	"control": fyne.KeyModifierControl,
+	"alt":     fyne.KeyModifierAlt,
+	"option":  fyne.KeyModifierAlt,
+	"super":   fyne.KeyModifierSuper,
+	"cmd":     fyne.KeyModifierSuper,
+	"meta":    fyne.KeyModifierSuper,
+}
+
+var chordKeyNames = map[string]fyne.KeyName{
+	"tab":       fyne.KeyTab,
+	"space":     fyne.KeySpace,
+	"return":    fyne.KeyReturn,
+	"enter":     fyne.KeyReturn,
+	"escape":    fyne.KeyEscape,
+	"esc":       fyne.KeyEscape,
+	"backspace": fyne.KeyBackspace,
+	"delete":    fyne.KeyDelete,
+	"insert":    fyne.KeyInsert,
+	"up":        fyne.KeyUp,
+	"down":      fyne.KeyDown,
+	"left":      fyne.KeyLeft,
+	"right":     fyne.KeyRight,
+	"home":      fyne.KeyHome,
+	"end":       fyne.KeyEnd,
+	"pageup":    fyne.KeyPageUp,
+	"pagedown":  fyne.KeyPageDown,
+}
+
+// parseKeyChord parses a chord such as "shift+tab", "ctrl+n" or "f2".
+func parseKeyChord(chord string) (keyChord, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(chord)), "+")
+	if len(parts) == 0 || parts[len(parts)-1] == "" {
+		return keyChord{}, fmt.Errorf("invalid key chord %q", chord)
+	}
+	var parsed keyChord
+	for _, part := range parts[:len(parts)-1] {
+		modifier, ok := chordModifiers[strings.TrimSpace(part)]
+		if !ok {
+			return keyChord{}, fmt.Errorf("invalid modifier %q in key chord %q", part, chord)
+		}
+		parsed.modifier |= modifier
+	}
+	key := strings.TrimSpace(parts[len(parts)-1])
+	switch {
+	case chordKeyNames[key] != "":
+		parsed.name = chordKeyNames[key]
+	case len(key) == 1 && (key[0] >= 'a' && key[0] <= 'z' || key[0] >= '0' && key[0] <= '9'):
+		parsed.name = fyne.KeyName(strings.ToUpper(key))
+	case len(key) >= 2 && key[0] == 'f' && isNumeric(key[1:]):
+		parsed.name = fyne.KeyName(strings.ToUpper(key))
+	default:
+		return keyChord{}, fmt.Errorf("invalid key %q in key chord %q", key, chord)
+	}
+	return parsed, nil
+}
+
+// ValidateKeyChord checks that chord is a valid key chord, so configs can be
+// checked before a menu is built.
+func ValidateKeyChord(chord string) error {
+	_, err := parseKeyChord(chord)
+	return err
+}
+
+// keyEventChord returns the chord of a plain key event. Plain key events
+// only carry shift state, chords with other modifiers arrive as shortcuts.
+func keyEventChord(key *fyne.KeyEvent, shiftPressed bool) keyChord {
+	chord := keyChord{name: key.Name}
+	if shiftPressed {
+		chord.modifier = fyne.KeyModifierShift
+	}
+	return chord
+}
+
+// shortcutChord returns the chord of a modifier shortcut.
+func shortcutChord(shortcut fyne.KeyboardShortcut) keyChord {
+	return keyChord{name: shortcut.Key(), modifier: shortcut.Mod()}
+}
+
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
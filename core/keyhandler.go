@@ -1,14 +1,12 @@
 package core
 
 import (
+	"math"
+
 	"fyne.io/fyne/v2"
 	"github.com/hamidzr/gmenu/model"
 )
 
-const (
-	queryChannelBufferSize = 100 // buffer size for query updates channel
-)
-
 // numericKeyToIndex converts numeric key names to zero-based indices
 func numericKeyToIndex(keyName fyne.KeyName) (int, bool) {
 	switch keyName {
@@ -38,16 +36,24 @@ func numericKeyToIndex(keyName fyne.KeyName) (int, bool) {
 // startListenDynamicUpdatesForMenu wires listeners for a specific menu instance.
 // Passing the menu explicitly avoids races when g.menu is swapped concurrently.
 func (g *GMenu) startListenDynamicUpdatesForMenu(m *menu) {
-	queryChan := make(chan string, queryChannelBufferSize) // buffered channel to prevent blocking
-	// Assign UI handler under UI mutex to avoid races when multiple setups occur
-	g.uiMutex.Lock()
-	g.ui.SearchEntry.OnChanged = func(text string) {
+	// queryUpdated and searched hold at most one signal. The query itself
+	// is read from the menu so the newest one is searched and none is lost.
+	queryUpdated := make(chan struct{}, 1)
+	searched := make(chan struct{}, 1)
+	signal := func(ch chan struct{}) {
 		select {
-		case queryChan <- text:
+		case ch <- struct{}{}:
 		default:
-			// drop update if channel is full to prevent blocking
 		}
 	}
+	// Assign UI handler under UI mutex to avoid races when multiple setups occur
+	g.uiMutex.Lock()
+	g.ui.SearchEntry.OnChanged = func(text string) {
+		m.queryMutex.Lock()
+		m.query = text
+		m.queryMutex.Unlock()
+		signal(queryUpdated)
+	}
 	g.uiMutex.Unlock()
 	// Dynamic resize disabled in tests to reduce UI races
 	go func() { // handle new characters in the search bar and new items loaded.
@@ -86,11 +92,12 @@ func (g *GMenu) startListenDynamicUpdatesForMenu(m *menu) {
 						filtered := append([]model.MenuItem(nil), m.Filtered...)
 						selected := m.Selected
 						m.itemsMutex.Unlock()
-						g.ui.ItemsCanvas.Render(filtered, selected, g.config.NoNumericSelection, g.handleItemClick)
+						g.ui.ItemsCanvas.Render(filtered, selected, g.conf().NoNumericSelection, g.handleItemClick)
 					}()
 				}
 				// Disabled dynamic resizing during tests to avoid UI races
 			})
+			g.updatePreview()
 		}
 
 		scheduleRender := func() {
@@ -106,35 +113,22 @@ func (g *GMenu) startListenDynamicUpdatesForMenu(m *menu) {
 
 		for {
 			select {
-			case query := <-queryChan:
-				if m != nil {
-					m.Search(query)
-					scheduleRender()
-				}
-			case items := <-m.ItemsChan:
-				if m != nil {
-					// Deduplicate and replace items under items lock
-					m.itemsMutex.Lock()
-					deduplicated := make([]model.MenuItem, 0, len(items))
-					seen := make(map[string]struct{}, len(items))
-					for _, item := range items {
-						key := item.ComputedTitle()
-						if _, ok := seen[key]; !ok {
-							seen[key] = struct{}{}
-							deduplicated = append(deduplicated, item)
-						}
+			// searches run on their own goroutines so a newer query can
+			// cancel a search in flight
+			case <-queryUpdated:
+				go func() {
+					if m.search(false) {
+						signal(searched)
 					}
-					m.items = deduplicated
-					// capture current query while holding query lock to search consistently
-					m.queryMutex.Lock()
-					currentQuery := m.query
-					m.queryMutex.Unlock()
-					m.itemsMutex.Unlock()
-
-					// Re-run search with current query (Search handles locking)
-					m.Search(currentQuery)
-					scheduleRender()
-				}
+				}()
+			case <-m.itemsUpdated:
+				go func() {
+					if m.refresh() {
+						signal(searched)
+					}
+				}()
+			case <-searched:
+				scheduleRender()
 			case <-renderRequests:
 				renderUI()
 			case <-m.ctx.Done():
@@ -147,43 +141,14 @@ func (g *GMenu) startListenDynamicUpdatesForMenu(m *menu) {
 
 func (g *GMenu) setKeyHandlers() {
 	keyHandler := func(key *fyne.KeyEvent) {
+		if action, ok := g.bindings().lookup(keyEventChord(key, g.ui.SearchEntry.ShiftPressed())); ok {
+			g.runKeyAction(action)
+			return
+		}
 		switch key.Name {
-		case fyne.KeyDown, fyne.KeyTab:
-			// Protect navigation state with menu items mutex
-			g.menu.itemsMutex.Lock()
-			if g.menu.Selected < len(g.menu.Filtered)-1 {
-				g.menu.Selected++
-			} else { // wrap
-				g.menu.Selected = 0
-			}
-			g.menu.itemsMutex.Unlock()
-
-		case fyne.KeyUp:
-			// Protect navigation state with menu items mutex
-			g.menu.itemsMutex.Lock()
-			if g.menu.Selected > 0 {
-				g.menu.Selected--
-			} else { // wrap
-				g.menu.Selected = len(g.menu.Filtered) - 1
-			}
-			g.menu.itemsMutex.Unlock()
-		case fyne.KeyReturn, fyne.KeyEnter:
-			// con't accept enter key if no items are present and custom selection is disabled.'
-			if !g.config.AcceptCustomSelection && len(g.menu.Filtered) == 0 {
-				return
-			}
-			g.ensureSelectionExitCode(model.NoError)
-			g.markSelectionMade()
-			// Complete selection with shared logic
-			g.completeSelection()
-		case fyne.KeyEscape:
-			g.exitCode = model.UserCanceled
-			g.markSelectionMade()
-			// Complete selection with shared logic
-			g.completeSelection()
 		case fyne.Key1, fyne.Key2, fyne.Key3, fyne.Key4, fyne.Key5, fyne.Key6, fyne.Key7, fyne.Key8, fyne.Key9:
 			// handle numeric selection if enabled
-			if !g.config.NoNumericSelection {
+			if !g.conf().NoNumericSelection {
 				if selectedIndex, ok := numericKeyToIndex(key.Name); ok {
 					// only select if the index is within bounds
 					if selectedIndex < len(g.menu.Filtered) {
@@ -196,25 +161,139 @@ func (g *GMenu) setKeyHandlers() {
 					}
 				}
 			}
-		default:
-			return
 		}
-		// Safely render UI components
-		g.uiMutex.Lock()
-		if g.ui != nil && g.ui.ItemsCanvas != nil && g.menu != nil {
-			// snapshot filtered data under lock for consistent render
-			g.menu.itemsMutex.Lock()
-			filtered := append([]model.MenuItem(nil), g.menu.Filtered...)
-			selected := g.menu.Selected
-			g.menu.itemsMutex.Unlock()
-			g.ui.ItemsCanvas.Render(filtered, selected, g.config.NoNumericSelection, g.handleItemClick)
+	}
+	shortcutHandler := func(shortcut fyne.KeyboardShortcut) bool {
+		action, ok := g.bindings().lookup(shortcutChord(shortcut))
+		if !ok {
+			return false
 		}
-		g.uiMutex.Unlock()
+		g.runKeyAction(action)
+		return true
 	}
 	// Assign under UI mutex to avoid concurrent writes in tests
 	g.uiMutex.Lock()
 	g.ui.SearchEntry.OnKeyDown = keyHandler
+	g.ui.SearchEntry.OnShortcut = shortcutHandler
 	g.uiMutex.Unlock()
 	// Note: MainWindow.Canvas().SetOnTypedKey() removed to prevent double key processing
 	// SearchEntry handles all keys via OnKeyDown and PropagationBlacklist
 }
+
+// runKeyAction performs a bound action in the GUI.
+func (g *GMenu) runKeyAction(action model.KeyAction) {
+	switch action {
+	case model.ActionDown:
+		// Protect navigation state with menu items mutex
+		g.menu.itemsMutex.Lock()
+		if g.menu.Selected < len(g.menu.Filtered)-1 {
+			g.menu.Selected++
+		} else { // wrap
+			g.menu.Selected = 0
+		}
+		g.menu.itemsMutex.Unlock()
+	case model.ActionUp:
+		// Protect navigation state with menu items mutex
+		g.menu.itemsMutex.Lock()
+		if g.menu.Selected > 0 {
+			g.menu.Selected--
+		} else { // wrap
+			g.menu.Selected = len(g.menu.Filtered) - 1
+		}
+		g.menu.itemsMutex.Unlock()
+	case model.ActionPageDown:
+		g.menu.moveSelection(g.ui.ItemsCanvas.PageSize())
+	case model.ActionPageUp:
+		g.menu.moveSelection(-g.ui.ItemsCanvas.PageSize())
+	case model.ActionFirst:
+		g.menu.selectIndex(0)
+	case model.ActionLast:
+		g.menu.selectIndex(math.MaxInt)
+	case model.ActionAccept:
+		g.accept(model.NoError)
+	case model.ActionCancel:
+		g.exitCode = model.UserCanceled
+		g.markSelectionMade()
+		// Complete selection with shared logic
+		g.completeSelection()
+	case model.ActionClearQuery:
+		g.ui.SearchEntry.SetText("")
+		return
+	case model.ActionToggleMark:
+		if g.conf().Multi {
+			g.toggleMark()
+		}
+		return
+	case model.ActionHistoryPrev:
+		if query, ok := g.menu.history.prev(g.ui.SearchEntry.Text); ok {
+			g.setQuery(query)
+		}
+		return
+	case model.ActionHistoryNext:
+		if query, ok := g.menu.history.next(); ok {
+			g.setQuery(query)
+		}
+		return
+	case model.ActionHistorySearch:
+		if query, ok := g.menu.history.search(g.ui.SearchEntry.Text); ok {
+			g.setQuery(query)
+		}
+		return
+	default:
+		if n, ok := action.CustomAccept(); ok {
+			g.accept(model.CustomAcceptCode(n))
+			break
+		}
+		return
+	}
+	g.renderItems()
+}
+
+// accept completes the selection with code, which tells the accept key from
+// the custom accept keys.
+func (g *GMenu) accept(code model.ExitCode) {
+	// con't accept enter key if no items are present and custom selection is disabled.'
+	if matches, _, _ := g.menu.counts(); !g.conf().AcceptCustomSelection && matches == 0 {
+		return
+	}
+	g.ensureSelectionExitCode(code)
+	g.markSelectionMade()
+	// Complete selection with shared logic
+	g.completeSelection()
+}
+
+// setQuery replaces the query with the cursor at its end.
+func (g *GMenu) setQuery(query string) {
+	g.ui.SearchEntry.SetText(query)
+	g.ui.SearchEntry.CursorColumn = len([]rune(query))
+	g.ui.SearchEntry.Refresh()
+}
+
+// renderItems re-renders the items canvas from the current menu state.
+func (g *GMenu) renderItems() {
+	// Safely render UI components
+	g.uiMutex.Lock()
+	defer g.uiMutex.Unlock()
+	if g.ui != nil && g.ui.ItemsCanvas != nil && g.menu != nil {
+		// snapshot filtered data under lock for consistent render
+		g.menu.itemsMutex.Lock()
+		filtered := append([]model.MenuItem(nil), g.menu.Filtered...)
+		selected := g.menu.Selected
+		g.menu.itemsMutex.Unlock()
+		g.ui.ItemsCanvas.Render(filtered, selected, g.conf().NoNumericSelection, g.handleItemClick)
+	}
+	g.updatePreview()
+}
+
+// toggleMark toggles the mark on the selected item and moves to the next one.
+func (g *GMenu) toggleMark() {
+	if !g.menu.toggleMarkSelected() {
+		return
+	}
+	g.menu.itemsMutex.Lock()
+	if g.menu.Selected < len(g.menu.Filtered)-1 {
+		g.menu.Selected++
+	}
+	g.menu.itemsMutex.Unlock()
+	g.renderItems()
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPidFileName = "gmenu"
+)
+
+// ErrInstanceLocked is returned when a running gmenu holds the lock of the
+// menu ID.
+var ErrInstanceLocked = errors.New("another instance of gmenu is already running")
+
+// errLockHeld is returned by lockFile when another process holds the lock.
+var errLockHeld = errors.New("lock is held")
+
+// InstanceLock keeps a second gmenu with the same menu ID from running. It
+// is an advisory lock on a file holding the owner's PID, which the kernel
+// releases when the owner exits, even if it crashes.
+type InstanceLock struct {
+	file *os.File
+	path string
+}
+
+// LockPath returns the lock file of menuID.
+func LockPath(menuID string) string {
+	if menuID == "" {
+		menuID = defaultPidFileName
+	}
+	return filepath.Join(os.TempDir(), menuID+".pid")
+}
+
+// AcquireInstanceLock takes the lock of menuID and writes the PID of this
+// process into it. A lock file left behind by a process that is gone is taken
+// over.
+func AcquireInstanceLock(menuID string) (*InstanceLock, error) {
+	path := LockPath(menuID)
+	for {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+		if err := lockFile(file); err != nil {
+			_ = file.Close()
+			if errors.Is(err, errLockHeld) {
+				return nil, fmt.Errorf("%w (pid %d), inspect the lock with 'gmenu lock status'", ErrInstanceLocked, readLockPID(path))
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		// the previous owner may have removed the file before we locked it
+		if !isLockedPath(file, path) {
+			_ = file.Close()
+			continue
+		}
+		if err := writeLockPID(file); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to write lock file: %w", err)
+		}
+		return &InstanceLock{file: file, path: path}, nil
+	}
+}
+
+// Release removes the lock file and releases the lock.
+func (l *InstanceLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	// remove the file while still holding the lock so nobody locks a file
+	// that is about to disappear
+	removeErr := os.Remove(l.path)
+	if errors.Is(removeErr, os.ErrNotExist) {
+		removeErr = nil
+	}
+	closeErr := l.file.Close()
+	l.file = nil
+	if removeErr != nil {
+		return fmt.Errorf("failed to remove lock file: %w", removeErr)
+	}
+	return closeErr
+}
+
+// LockStatus describes the lock file of a menu ID.
+type LockStatus struct {
+	Path string
+	// Exists is false when there is no lock file.
+	Exists bool
+	// Held reports whether a running process holds the lock.
+	Held bool
+	// PID is the process recorded in the lock file, 0 when there is none.
+	PID int
+	// Alive reports whether the process PID is running.
+	Alive bool
+}
+
+// String describes the status in a sentence.
+func (s LockStatus) String() string {
+	switch {
+	case !s.Exists:
+		return "unlocked, no lock file"
+	case s.Held:
+		return fmt.Sprintf("locked by running process %d", s.PID)
+	case s.PID != 0 && s.Alive:
+		return fmt.Sprintf("stale, process %d is running but doesn't hold the lock", s.PID)
+	case s.PID != 0:
+		return fmt.Sprintf("stale, left behind by process %d which is gone", s.PID)
+	default:
+		return "stale, the lock file has no pid"
+	}
+}
+
+// InstanceLockStatus inspects the lock of menuID without taking it.
+func InstanceLockStatus(menuID string) (LockStatus, error) {
+	status := LockStatus{Path: LockPath(menuID)}
+	file, err := os.OpenFile(status.Path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	status.Exists = true
+	status.PID = readLockPID(status.Path)
+	status.Alive = status.PID != 0 && processAlive(status.PID)
+	if err := lockFile(file); err != nil {
+		if !errors.Is(err, errLockHeld) {
+			return status, fmt.Errorf("failed to check lock: %w", err)
+		}
+		status.Held = true
+	}
+	return status, nil
+}
+
+// ClearInstanceLock removes the lock file of menuID unless a running process
+// holds it.
+func ClearInstanceLock(menuID string) error {
+	path := LockPath(menuID)
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	if err := lockFile(file); err != nil {
+		if errors.Is(err, errLockHeld) {
+			return fmt.Errorf("%w (pid %d), not clearing %s", ErrInstanceLocked, readLockPID(path), path)
+		}
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// isLockedPath reports whether path still names the locked file.
+func isLockedPath(file *os.File, path string) bool {
+	locked, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(locked, current)
+}
+
+// writeLockPID replaces the content of the lock file with the PID of this
+// process.
+func writeLockPID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// readLockPID returns the PID recorded in the lock file, or 0.
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,23 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package core
+
+import (
+	"os"
+)
+
+// lockFile treats the lock as held while the process recorded in file is
+// running, since flock isn't available on this platform.
+func lockFile(file *os.File) error {
+	pid := readLockPID(file.Name())
+	if pid != 0 && pid != os.Getpid() && processAlive(pid) {
+		return errLockHeld
+	}
+	return nil
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
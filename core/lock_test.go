@@ -0,0 +1,64 @@
+package core
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceLock(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	status, err := InstanceLockStatus("menu")
+	require.NoError(t, err)
+	assert.False(t, status.Exists)
+
+	lock, err := AcquireInstanceLock("menu")
+	require.NoError(t, err)
+	data, err := os.ReadFile(LockPath("menu"))
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(data))
+
+	_, err = AcquireInstanceLock("menu")
+	assert.ErrorIs(t, err, ErrInstanceLocked)
+	// other menu IDs have their own lock
+	other, err := AcquireInstanceLock("other")
+	require.NoError(t, err)
+	require.NoError(t, other.Release())
+
+	status, err = InstanceLockStatus("menu")
+	require.NoError(t, err)
+	assert.True(t, status.Held)
+	assert.Equal(t, os.Getpid(), status.PID)
+	assert.ErrorIs(t, ClearInstanceLock("menu"), ErrInstanceLocked)
+
+	require.NoError(t, lock.Release())
+	assert.NoFileExists(t, LockPath("menu"))
+	require.NoError(t, lock.Release(), "releasing twice is harmless")
+}
+
+func TestInstanceLockStale(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// a lock file left behind by a crashed process isn't locked
+	require.NoError(t, os.WriteFile(LockPath(""), []byte("999999999\n"), 0o600))
+	status, err := InstanceLockStatus("")
+	require.NoError(t, err)
+	assert.True(t, status.Exists)
+	assert.False(t, status.Held)
+	assert.False(t, status.Alive)
+	assert.Equal(t, 999999999, status.PID)
+	assert.Contains(t, status.String(), "stale")
+
+	lock, err := AcquireInstanceLock("")
+	require.NoError(t, err, "stale lock files are taken over")
+	require.NoError(t, lock.Release())
+
+	// the pid file of older versions is empty
+	require.NoError(t, os.WriteFile(LockPath(""), nil, 0o600))
+	require.NoError(t, ClearInstanceLock(""))
+	assert.NoFileExists(t, LockPath(""))
+}
@@ -2,10 +2,12 @@ package core
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/hamidzr/gmenu/constant"
 	"github.com/hamidzr/gmenu/model"
+	"github.com/sirupsen/logrus"
 )
 
 type menu struct {
@@ -14,7 +16,15 @@ type menu struct {
 	itemsMutex sync.Mutex
 	ctx        context.Context
 	queryMutex sync.Mutex
-	ItemsChan  chan []model.MenuItem
+	// itemsUpdated is signalled when items are added or replaced so the
+	// listener can re-run the current query.
+	itemsUpdated chan struct{}
+	// keys holds the keys of items to skip duplicates when adding items.
+	keys map[string]struct{}
+	// placeholder is set while items only holds the loading item.
+	placeholder bool
+	// loading is set while more items are still being read.
+	loading bool
 
 	Filtered []model.MenuItem
 	// zero-based index of the selected item in the filtered list
@@ -24,7 +34,37 @@ type menu struct {
 	MatchCount    int
 	SearchMethod  SearchMethod
 	preserveOrder bool
-	resultLimit   int
+	// ignoreDiacritics strips diacritics from the items and the query
+	// before they are compared.
+	ignoreDiacritics bool
+	// resultLimit caps the filtered list. 0 keeps every match.
+	resultLimit int
+	// itemFormat splits structured input lines into fields.
+	itemFormat model.ItemFormat
+	// marked holds the keys of items marked in multi-select mode.
+	marked map[string]struct{}
+	// history holds the queries of earlier selections of the menu ID.
+	history *queryHistory
+
+	// narrowing is set when SearchMethod only narrows the matches of a
+	// query as it grows, see narrowingSearchMethods.
+	narrowing bool
+	// cancelSearch stops the search in flight. searchID counts searches so
+	// only the newest one shows its result.
+	cancelSearch context.CancelFunc
+	searchID     uint64
+	// itemsVersion changes whenever items do.
+	itemsVersion uint64
+	// lastSearch is the result of the last completed search.
+	lastSearch searchResult
+}
+
+// searchResult holds every match of a query, before the result limit, over
+// a version of the items.
+type searchResult struct {
+	query   string
+	matches []model.MenuItem
+	version uint64
 }
 
 func newMenu(
@@ -33,20 +73,32 @@ func newMenu(
 	initValue string,
 	searchMethod SearchMethod,
 	preserveOrder bool,
+	ignoreDiacritics bool,
+	resultLimit int,
+	itemFormat model.ItemFormat,
 ) (*menu, error) {
 	m := menu{
-		ctx:           ctx,
-		Selected:      0,
-		SearchMethod:  searchMethod,
-		resultLimit:   10,
-		ItemsChan:     make(chan []model.MenuItem, 10), // bounded channel to prevent memory leaks
-		query:         initValue,
-		preserveOrder: preserveOrder,
+		ctx:              ctx,
+		Selected:         0,
+		SearchMethod:     searchMethod,
+		resultLimit:      resultLimit,
+		itemsUpdated:     make(chan struct{}, 1),
+		query:            initValue,
+		preserveOrder:    preserveOrder,
+		ignoreDiacritics: ignoreDiacritics,
+		itemFormat:       itemFormat,
+		marked:           make(map[string]struct{}),
+		history:          newQueryHistory(nil),
 	}
 	items := m.titlesToMenuItem(itemTitles)
 
+	m.keys = make(map[string]struct{}, len(items))
+	for _, item := range items {
+		m.keys[item.Key()] = struct{}{}
+	}
 	if len(items) == 0 {
 		items = []model.MenuItem{model.LoadingItem}
+		m.placeholder = true
 	}
 	m.items = items
 
@@ -54,39 +106,282 @@ func newMenu(
 	return &m, nil
 }
 
-// Filters the menu filtered list to only include items that match the keyword.
-func (m *menu) Search(keyword string) {
-	// Update the query under its own lock
+// Search filters the items by keyword and selects the first match. A newer
+// search cancels it, and Search then reports false as the filtered list holds
+// the newer result.
+func (m *menu) Search(keyword string) bool {
 	m.queryMutex.Lock()
 	m.query = keyword
 	m.queryMutex.Unlock()
+	return m.search(false)
+}
 
-	// Compute filtered results and update shared menu state under items lock
+// refresh re-runs the current query after the items changed. The selected
+// item stays selected when it is still listed.
+func (m *menu) refresh() bool {
+	return m.search(true)
+}
+
+// search runs the search method over the current query without holding
+// itemsMutex so the menu stays responsive on large item sets. It cancels the
+// search in flight and reports whether its own result made it to the
+// filtered list. Empty queries go through the search method too so wrappers
+// like FrecencySearch can order them.
+func (m *menu) search(keepSelection bool) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	m.itemsMutex.Lock()
-	if keyword == "" {
-		// Use current items snapshot directly
-		m.Filtered = m.items
-	} else {
-		// Run search based on a stable snapshot of items
-		itemsSnapshot := m.items
-		m.Filtered = m.SearchMethod(itemsSnapshot, keyword, m.preserveOrder, 0)
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+	}
+	m.cancelSearch = cancel
+	m.searchID++
+	// the newest search has to see the newest query
+	m.queryMutex.Lock()
+	query := m.query
+	m.queryMutex.Unlock()
+	if m.ignoreDiacritics {
+		query = model.StripDiacritics(query)
+	}
+	id, version, items, last := m.searchID, m.itemsVersion, m.items, m.lastSearch
+	placeholder := m.placeholder
+	m.itemsMutex.Unlock()
+
+	if m.narrowing && last.version == version && last.query != "" && strings.HasPrefix(query, last.query) {
+		items = matchesInInputOrder(items, last.matches)
+	}
+	// the loading placeholder is shown but never matched
+	var matches []model.MenuItem
+	if !placeholder {
+		matches = m.SearchMethod(ctx, items, query, m.preserveOrder, 0)
+	}
+
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	if ctx.Err() != nil || id != m.searchID {
+		return false
+	}
+	m.cancelSearch = nil
+	m.lastSearch = searchResult{query: query, matches: matches, version: version}
+
+	var selectedKey string
+	if keepSelection && m.Selected >= 0 && m.Selected < len(m.Filtered) {
+		selectedKey = m.Filtered[m.Selected].Key()
+	}
+	m.MatchCount = len(matches)
+	m.Filtered = applyLimit(matches, m.resultLimit)
+	if m.placeholder {
+		m.Filtered = []model.MenuItem{model.LoadingItem}
 	}
+	m.Selected = constant.UnsetInt
 	if len(m.Filtered) > 0 {
 		m.Selected = 0
-	} else {
-		m.Selected = constant.UnsetInt
 	}
-	m.MatchCount = len(m.Filtered)
-	if len(m.Filtered) > m.resultLimit {
-		m.Filtered = m.Filtered[:m.resultLimit]
+	for i, item := range m.Filtered {
+		if selectedKey != "" && item.Key() == selectedKey {
+			m.Selected = i
+			break
+		}
+	}
+	return true
+}
+
+// matchesInInputOrder returns the items that are among matches, in the
+// order of items, so ties rank the same as in a search over every item.
+func matchesInInputOrder(items, matches []model.MenuItem) []model.MenuItem {
+	keys := make(map[string]struct{}, len(matches))
+	for _, item := range matches {
+		keys[item.Key()] = struct{}{}
+	}
+	subset := make([]model.MenuItem, 0, len(matches))
+	for _, item := range items {
+		if _, ok := keys[item.Key()]; ok {
+			subset = append(subset, item)
+		}
+	}
+	return subset
+}
+
+// setItems replaces the items, dropping duplicates.
+func (m *menu) setItems(items []model.MenuItem) {
+	m.itemsMutex.Lock()
+	m.items = make([]model.MenuItem, 0, len(items))
+	m.keys = make(map[string]struct{}, len(items))
+	m.placeholder = false
+	m.addItemsLocked(items)
+	m.itemsMutex.Unlock()
+	m.notifyItemsUpdated()
+}
+
+// appendItems adds items after the existing ones, skipping duplicates.
+func (m *menu) appendItems(items []model.MenuItem) {
+	m.itemsMutex.Lock()
+	if m.placeholder {
+		m.items = nil
+		m.placeholder = false
+	}
+	m.addItemsLocked(items)
+	m.itemsMutex.Unlock()
+	m.notifyItemsUpdated()
+}
+
+// prependItems adds items before the existing ones, skipping duplicates.
+func (m *menu) prependItems(items []model.MenuItem) {
+	m.itemsMutex.Lock()
+	existing := m.items
+	if m.placeholder {
+		existing = nil
+	}
+	m.items = make([]model.MenuItem, 0, len(items)+len(existing))
+	m.keys = make(map[string]struct{}, len(items)+len(existing))
+	m.placeholder = false
+	m.addItemsLocked(items)
+	m.addItemsLocked(existing)
+	m.itemsMutex.Unlock()
+	m.notifyItemsUpdated()
+}
+
+func (m *menu) addItemsLocked(items []model.MenuItem) {
+	m.itemsVersion++
+	for _, item := range items {
+		key := item.Key()
+		if _, ok := m.keys[key]; ok {
+			continue
+		}
+		m.keys[key] = struct{}{}
+		if item.Search == nil {
+			item.Search = model.NewSearchText(item.ComputedTitle(), m.ignoreDiacritics)
+		}
+		m.items = append(m.items, item)
 	}
+}
+
+// setLoading records whether more items are still being read.
+func (m *menu) setLoading(loading bool) {
+	m.itemsMutex.Lock()
+	m.loading = loading
 	m.itemsMutex.Unlock()
+	m.notifyItemsUpdated()
+}
+
+// counts returns the number of matches and items, and whether items are
+// still loading.
+func (m *menu) counts() (matches, total int, loading bool) {
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	if m.placeholder {
+		return 0, 0, m.loading
+	}
+	return m.MatchCount, len(m.items), m.loading
+}
+
+func (m *menu) notifyItemsUpdated() {
+	select {
+	case m.itemsUpdated <- struct{}{}:
+	default:
+	}
+}
+
+// moveSelection moves the selection by delta items, stopping at either end
+// of the filtered list.
+func (m *menu) moveSelection(delta int) {
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	m.selectLocked(m.Selected + delta)
+}
+
+// selectedLocked returns the selected item, or nil when nothing or only the
+// loading placeholder is listed. itemsMutex must be held.
+func (m *menu) selectedLocked() *model.MenuItem {
+	if m.placeholder || m.Selected < 0 || m.Selected >= len(m.Filtered) {
+		return nil
+	}
+	selected := m.Filtered[m.Selected]
+	return &selected
+}
+
+// selectIndex selects the item at idx, clamped to the filtered list.
+func (m *menu) selectIndex(idx int) {
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	m.selectLocked(idx)
+}
+
+func (m *menu) selectLocked(idx int) {
+	if len(m.Filtered) == 0 {
+		return
+	}
+	m.Selected = min(max(idx, 0), len(m.Filtered)-1)
 }
 
 func (m *menu) titlesToMenuItem(titles []string) []model.MenuItem {
 	items := make([]model.MenuItem, len(titles))
 	for i, entry := range titles {
-		items[i] = model.MenuItem{Title: entry}
+		item, err := m.itemFormat.Parse(entry)
+		if err != nil {
+			logrus.Warnf("failed to parse item %q: %v", entry, err)
+		}
+		item.Search = model.NewSearchText(item.ComputedTitle(), m.ignoreDiacritics)
+		items[i] = item
 	}
 	return items
 }
+
+// toggleMarkSelected toggles the mark on the selected item.
+// It returns false when there is no item to mark.
+func (m *menu) toggleMarkSelected() bool {
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	if m.Selected < 0 || m.Selected >= len(m.Filtered) {
+		return false
+	}
+	if m.placeholder {
+		return false
+	}
+	item := m.Filtered[m.Selected]
+	key := item.Key()
+	if _, ok := m.marked[key]; ok {
+		delete(m.marked, key)
+	} else {
+		m.marked[key] = struct{}{}
+	}
+	return true
+}
+
+// isMarked reports whether an item is marked.
+func (m *menu) isMarked(item model.MenuItem) bool {
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	_, ok := m.marked[item.Key()]
+	return ok
+}
+
+// markedItems returns the marked items in input order.
+func (m *menu) markedItems() []model.MenuItem {
+	m.itemsMutex.Lock()
+	defer m.itemsMutex.Unlock()
+	if len(m.marked) == 0 {
+		return nil
+	}
+	marked := make([]model.MenuItem, 0, len(m.marked))
+	seen := make(map[string]struct{}, len(m.marked))
+	for _, item := range m.items {
+		key := item.Key()
+		if _, ok := m.marked[key]; !ok {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		marked = append(marked, item)
+	}
+	return marked
+}
+
+// clearMarks removes all marks.
+func (m *menu) clearMarks() {
+	m.itemsMutex.Lock()
+	m.marked = make(map[string]struct{})
+	m.itemsMutex.Unlock()
+}
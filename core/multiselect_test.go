@@ -0,0 +1,131 @@
+package core
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyChord(t *testing.T) {
+	tests := []struct {
+		chord    string
+		expected keyChord
+		wantErr  bool
+	}{
+		{chord: "shift+tab", expected: keyChord{name: fyne.KeyTab, modifier: fyne.KeyModifierShift}},
+		{chord: "Ctrl+Space", expected: keyChord{name: fyne.KeySpace, modifier: fyne.KeyModifierControl}},
+		{chord: "ctrl+alt+k", expected: keyChord{name: fyne.KeyK, modifier: fyne.KeyModifierControl | fyne.KeyModifierAlt}},
+		{chord: "f2", expected: keyChord{name: fyne.KeyF2}},
+		{chord: "pagedown", expected: keyChord{name: fyne.KeyPageDown}},
+		{chord: "", wantErr: true},
+		{chord: "hyper+x", wantErr: true},
+		{chord: "ctrl+", wantErr: true},
+		{chord: "ctrl+nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chord, func(t *testing.T) {
+			chord, err := parseKeyChord(tt.chord)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, chord)
+		})
+	}
+}
+
+func newMultiTestGMenu(t *testing.T, markKey string) *GMenu {
+	t.Helper()
+	useFyneTestApp(t)
+	config := &model.Config{
+		Title:     "Multi Test",
+		Prompt:    "test>",
+		MinWidth:  300,
+		MinHeight: 200,
+		Multi:     true,
+		MarkKey:   markKey,
+	}
+	gmenu, err := NewGMenu(DirectSearch, config)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NoError(t, gmenu.SetupMenu([]string{"alpha", "beta", "gamma", "delta"}, ""))
+	return gmenu
+}
+
+func pressShiftTab(gmenu *GMenu) {
+	shift := &fyne.KeyEvent{Name: desktop.KeyShiftLeft}
+	gmenu.ui.SearchEntry.KeyDown(shift)
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	gmenu.ui.SearchEntry.KeyUp(shift)
+}
+
+func TestMultiSelectMarksInInputOrder(t *testing.T) {
+	gmenu := newMultiTestGMenu(t, "")
+
+	// mark gamma first, then alpha, then toggle beta on and off again
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	pressShiftTab(gmenu)
+	gmenu.menu.Selected = 0
+	pressShiftTab(gmenu)
+	pressShiftTab(gmenu)
+	gmenu.menu.Selected = 1
+	pressShiftTab(gmenu)
+
+	assert.True(t, gmenu.isMarked(model.MenuItem{Title: "alpha"}))
+	assert.False(t, gmenu.isMarked(model.MenuItem{Title: "beta"}))
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	values, err := gmenu.SelectedValues()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "gamma"}, itemsToStr(values))
+}
+
+func TestMultiSelectPlainTabStillNavigates(t *testing.T) {
+	gmenu := newMultiTestGMenu(t, "")
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, 1, gmenu.menu.Selected)
+	assert.Empty(t, gmenu.menu.markedItems())
+}
+
+func TestMultiSelectFallsBackToSelectedItem(t *testing.T) {
+	gmenu := newMultiTestGMenu(t, "")
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	values, err := gmenu.SelectedValues()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beta"}, itemsToStr(values))
+}
+
+func TestMultiSelectCustomMarkKey(t *testing.T) {
+	gmenu := newMultiTestGMenu(t, "ctrl+space")
+
+	handled := gmenu.ui.SearchEntry.OnShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeySpace,
+		Modifier: fyne.KeyModifierControl,
+	})
+	assert.True(t, handled)
+	assert.Equal(t, []string{"alpha"}, itemsToStr(gmenu.menu.markedItems()))
+
+	// shift+tab is not a mark key anymore
+	pressShiftTab(gmenu)
+	assert.Equal(t, []string{"alpha"}, itemsToStr(gmenu.menu.markedItems()))
+}
+
+func TestMultiSelectInvalidMarkKey(t *testing.T) {
+	useFyneTestApp(t)
+	_, err := NewGMenu(DirectSearch, &model.Config{Multi: true, MarkKey: "hyper+x"})
+	assert.Error(t, err)
+}
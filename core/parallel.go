@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/hamidzr/gmenu/model"
+)
+
+const (
+	// searchShardSize is the least number of items worth a goroutine.
+	searchShardSize = 8192
+	// searchCheckInterval is how many items are matched between checks for
+	// a cancelled search.
+	searchCheckInterval = 1024
+)
+
+// matchItems calls match on every item and returns the results of the
+// matching ones in input order. match gets a pointer to skip copying items it
+// rejects and must not modify the item. Large item sets are split into shards that
+// are matched concurrently. It returns nil once ctx is done.
+func matchItems[T any](ctx context.Context, items []model.MenuItem, match func(*model.MenuItem) (T, bool)) []T {
+	shards := min(runtime.GOMAXPROCS(0), (len(items)+searchShardSize-1)/searchShardSize)
+	if shards <= 1 {
+		return matchShard(ctx, items, match)
+	}
+	size := (len(items) + shards - 1) / shards
+	results := make([][]T, shards)
+	var wg sync.WaitGroup
+	for i := range results {
+		shard := items[i*size : min((i+1)*size, len(items))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = matchShard(ctx, shard, match)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	total := 0
+	for _, r := range results {
+		total += len(r)
+	}
+	matches := make([]T, 0, total)
+	for _, r := range results {
+		matches = append(matches, r...)
+	}
+	return matches
+}
+
+func matchShard[T any](ctx context.Context, items []model.MenuItem, match func(*model.MenuItem) (T, bool)) []T {
+	matches := make([]T, 0)
+	for i := range items {
+		if i%searchCheckInterval == 0 && ctx.Err() != nil {
+			return nil
+		}
+		if result, ok := match(&items[i]); ok {
+			matches = append(matches, result)
+		}
+	}
+	return matches
+}
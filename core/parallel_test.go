@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numberedItems(n int) []model.MenuItem {
+	items := make([]model.MenuItem, n)
+	for i := range items {
+		items[i] = model.MenuItem{Title: fmt.Sprintf("item-%06d", i)}
+	}
+	return items
+}
+
+func TestMatchItems(t *testing.T) {
+	items := numberedItems(10 * searchShardSize)
+	even := func(item *model.MenuItem) (string, bool) {
+		return item.Title, item.Title[len(item.Title)-1]%2 == 0
+	}
+
+	t.Run("keeps input order across shards", func(t *testing.T) {
+		matches := matchItems(context.Background(), items, even)
+		require.Len(t, matches, len(items)/2)
+		for i, title := range matches {
+			assert.Equal(t, items[2*i].Title, title)
+		}
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Nil(t, matchItems(ctx, items, even))
+	})
+}
+
+func TestSearchLargeItemSets(t *testing.T) {
+	items := numberedItems(5 * searchShardSize)
+	for _, name := range []string{"direct", "fuzzy", "extended"} {
+		t.Run(name, func(t *testing.T) {
+			// items containing the query come first in input order
+			res := SearchMethods[name](context.Background(), items, "item-0000", false, 0)
+			require.GreaterOrEqual(t, len(res), 100)
+			assert.Equal(t, itemsToStr(items[:100]), itemsToStr(res[:100]))
+		})
+	}
+}
+
+func TestMenuSearch(t *testing.T) {
+	titles := []string{"alpha", "alphabet", "beta", "alps", "gamma"}
+
+	t.Run("narrowing reuses the last matches", func(t *testing.T) {
+		var searched []int
+		method := func(ctx context.Context, items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
+			searched = append(searched, len(items))
+			return DirectSearch(ctx, items, query, preserveOrder, limit)
+		}
+		m, err := newMenu(context.Background(), titles, "", method, false, false, 0, model.ItemFormat{})
+		require.NoError(t, err)
+		m.narrowing = true
+
+		m.Search("al")
+		m.Search("alp")
+		m.Search("alpha")
+		assert.Equal(t, []string{"alpha", "alphabet"}, itemsToStr(m.Filtered))
+		m.Search("be")
+		assert.Equal(t, []string{"alphabet", "beta"}, itemsToStr(m.Filtered))
+		// the empty initial query and "be" search every item
+		assert.Equal(t, []int{5, 5, 3, 3, 5}, searched)
+	})
+
+	t.Run("new items are searched", func(t *testing.T) {
+		m, err := newMenu(context.Background(), titles, "", DirectSearch, false, false, 0, model.ItemFormat{})
+		require.NoError(t, err)
+		m.narrowing = true
+
+		m.Search("al")
+		m.appendItems(strToItems(&[]string{"also"}))
+		m.Search("als")
+		assert.Equal(t, []string{"also"}, itemsToStr(m.Filtered))
+	})
+
+	t.Run("newer search wins", func(t *testing.T) {
+		started := make(chan struct{})
+		method := func(ctx context.Context, items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
+			if query == "slow" {
+				close(started)
+				<-ctx.Done()
+			}
+			return DirectSearch(ctx, items, query, preserveOrder, limit)
+		}
+		m, err := newMenu(context.Background(), titles, "", method, false, false, 0, model.ItemFormat{})
+		require.NoError(t, err)
+
+		done := make(chan bool)
+		go func() { done <- m.Search("slow") }()
+		<-started
+		assert.True(t, m.Search("beta"))
+		select {
+		case applied := <-done:
+			assert.False(t, applied)
+		case <-time.After(time.Second):
+			t.Fatal("stale search was not cancelled")
+		}
+		assert.Equal(t, []string{"beta"}, itemsToStr(m.Filtered))
+	})
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// PreviewDebounce is how long the selection has to settle before the
+	// preview command runs.
+	PreviewDebounce = 100 * time.Millisecond
+	// maxPreviewBytes caps how much command output is kept for display.
+	maxPreviewBytes = 64 * 1024
+)
+
+// Previewer runs a preview command for the selected entry. Requests are
+// debounced and a newer request cancels the command that is still running.
+type Previewer struct {
+	command  string
+	debounce time.Duration
+	onOutput func(output string)
+
+	mu     sync.Mutex
+	seq    uint64
+	last   string
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+// NewPreviewer creates a previewer for a command template where {} is
+// replaced by the shell quoted entry. onOutput receives the command output.
+func NewPreviewer(command string, debounce time.Duration, onOutput func(output string)) *Previewer {
+	return &Previewer{
+		command:  command,
+		debounce: debounce,
+		onOutput: onOutput,
+	}
+}
+
+// Request schedules a preview of entry. An empty entry clears the preview.
+// Requesting the entry that is already shown is a no-op.
+func (p *Previewer) Request(entry string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.seq > 0 && entry == p.last {
+		return
+	}
+	p.stopLocked()
+	p.seq++
+	p.last = entry
+	if entry == "" {
+		go p.onOutput("")
+		return
+	}
+
+	seq := p.seq
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.timer = time.AfterFunc(p.debounce, func() {
+		output := runPreviewCommand(ctx, previewCommand(p.command, entry))
+		p.mu.Lock()
+		current := seq == p.seq && ctx.Err() == nil
+		p.mu.Unlock()
+		if current {
+			p.onOutput(output)
+		}
+	})
+}
+
+// Stop cancels any pending or running preview.
+func (p *Previewer) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopLocked()
+	p.seq++
+	p.last = ""
+}
+
+func (p *Previewer) stopLocked() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
+
+// previewCommand substitutes the shell quoted entry for every {} in command.
+func previewCommand(command, entry string) string {
+	return strings.ReplaceAll(command, "{}", shellQuote(entry))
+}
+
+// shellQuote quotes s for use as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runPreviewCommand runs command with sh and returns its combined output,
+// followed by the error when the command fails.
+func runPreviewCommand(ctx context.Context, command string) string {
+	output := &limitedBuffer{limit: maxPreviewBytes}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// don't wait on children that keep the output pipe open after a cancel.
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if err != nil && ctx.Err() == nil {
+		return strings.TrimRight(output.String(), "\n") + "\n[" + err.Error() + "]"
+	}
+	return output.String()
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
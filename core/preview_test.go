@@ -0,0 +1,117 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewCommand(t *testing.T) {
+	assert.Equal(t, "cat 'a b.txt'", previewCommand("cat {}", "a b.txt"))
+	assert.Equal(t, `echo 'it'\''s' 'it'\''s'`, previewCommand("echo {} {}", "it's"))
+	assert.Equal(t, "git log", previewCommand("git log", "main"))
+}
+
+// previewRecorder collects preview outputs.
+type previewRecorder struct {
+	mu      sync.Mutex
+	outputs []string
+}
+
+func (r *previewRecorder) record(output string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outputs = append(r.outputs, output)
+}
+
+func (r *previewRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.outputs...)
+}
+
+func TestPreviewerDebounces(t *testing.T) {
+	recorder := &previewRecorder{}
+	previewer := NewPreviewer("echo {}", 50*time.Millisecond, recorder.record)
+	defer previewer.Stop()
+
+	previewer.Request("a")
+	previewer.Request("b")
+	previewer.Request("c")
+	require.Eventually(t, func() bool { return len(recorder.get()) > 0 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"c\n"}, recorder.get())
+
+	// the same entry is not previewed twice
+	previewer.Request("c")
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, recorder.get(), 1)
+}
+
+func TestPreviewerCancelsRunningCommand(t *testing.T) {
+	recorder := &previewRecorder{}
+	previewer := NewPreviewer(`[ {} = slow ] && sleep 2; echo {}`, 10*time.Millisecond, recorder.record)
+	defer previewer.Stop()
+
+	previewer.Request("slow")
+	time.Sleep(100 * time.Millisecond) // let the slow command start
+	previewer.Request("fast")
+	require.Eventually(t, func() bool { return len(recorder.get()) > 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"fast\n"}, recorder.get())
+}
+
+func TestPreviewerReportsErrors(t *testing.T) {
+	recorder := &previewRecorder{}
+	previewer := NewPreviewer("echo oops; exit 3", 10*time.Millisecond, recorder.record)
+	defer previewer.Stop()
+
+	previewer.Request("x")
+	require.Eventually(t, func() bool { return len(recorder.get()) > 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "oops\n[exit status 3]", recorder.get()[0])
+}
+
+func TestPreviewFollowsSelection(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{
+		Title:           "Preview Test",
+		Prompt:          "Search",
+		MinWidth:        300,
+		MinHeight:       200,
+		Preview:         "echo preview:{}",
+		PreviewPosition: "bottom",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		gmenu.previewer.Stop()
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NotNil(t, gmenu.ui.Preview)
+	previewText := func() string {
+		var text string
+		gmenu.safeUIUpdate(func() { text = gmenu.ui.Preview.Text() })
+		return text
+	}
+
+	require.NoError(t, gmenu.SetupMenu([]string{"alpha", "beta"}, ""))
+	require.Eventually(t, func() bool {
+		return previewText() == "preview:alpha"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	require.Eventually(t, func() bool {
+		return previewText() == "preview:beta"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestInvalidPreviewPosition(t *testing.T) {
+	useFyneTestApp(t)
+	_, err := NewGMenu(DirectSearch, &model.Config{Preview: "cat {}", PreviewPosition: "left"})
+	assert.Error(t, err)
+}
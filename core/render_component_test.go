@@ -46,7 +46,7 @@ func TestGRenderMatchCounterLabel(t *testing.T) {
 			name:           "no items",
 			items:          []string{},
 			query:          "",
-			expectedFormat: "[1/1]", // includes loading item
+			expectedFormat: "[0/0]", // the loading item isn't counted
 		},
 		{
 			name:           "all items visible",
@@ -146,12 +146,12 @@ func TestGRenderEmptyAndNilStates(t *testing.T) {
 	// Test with nil items
 	require.NoError(t, gmenu.SetupMenu(nil, ""))
 	label := gmenu.matchCounterLabel()
-	assert.Contains(t, label, "/1]") // should show loading item
+	assert.Equal(t, "[0/0]", label) // the loading item isn't counted
 
 	// Test with empty slice
 	require.NoError(t, gmenu.SetupMenu([]string{}, ""))
 	label = gmenu.matchCounterLabel()
-	assert.Contains(t, label, "/1]") // should show loading item
+	assert.Equal(t, "[0/0]", label) // the loading item isn't counted
 
 	// Test with items containing empty strings
 	require.NoError(t, gmenu.SetupMenu([]string{"", "valid", ""}, ""))
@@ -0,0 +1,334 @@
+package core
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hamidzr/gmenu/model"
+)
+
+// Scores of a fuzzy match, modelled after fzf. Every matched character is
+// worth scoreMatch, gaps between matched characters cost a penalty and
+// characters at the start of words earn a bonus.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	// bonusBoundary is for a character after a non-word character.
+	bonusBoundary = scoreMatch / 2
+	// bonusBoundaryWhite is for a character after a space or at the start.
+	bonusBoundaryWhite = bonusBoundary + 2
+	// bonusBoundaryDelimiter is for a character after a path separator or
+	// another delimiter.
+	bonusBoundaryDelimiter = bonusBoundary + 1
+	// bonusNonWord is for matching a non-word character itself.
+	bonusNonWord = scoreMatch / 2
+	// bonusCamel123 is for a camelCase hump or the first digit of a number.
+	bonusCamel123 = bonusBoundary + scoreGapExtension
+	// bonusConsecutive is the least bonus of a character right after
+	// another matched one.
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+	// bonusFirstCharMultiplier weighs the bonus of the first query character,
+	// so prefix and word start hits rank higher.
+	bonusFirstCharMultiplier = 2
+	// bonusSubstring is for an item containing the query term as is, so it
+	// ranks above scattered matches of the same characters.
+	bonusSubstring = 2 * scoreMatch
+
+	// maxScoredCells bounds the size of the scoring table. Longer titles
+	// are scored along the shortest match instead.
+	maxScoredCells = 1 << 16
+)
+
+// charClass is the kind of a character for the word boundary bonuses.
+// Word characters come after charDelimiter.
+type charClass int
+
+const (
+	charWhite charClass = iota
+	charNonWord
+	charDelimiter
+	charLower
+	charUpper
+	charLetter
+	charNumber
+)
+
+// delimiterChars separate words in paths and lists.
+const delimiterChars = "/\\,:;|"
+
+// asciiClasses holds the class of every ASCII character.
+var asciiClasses = func() (classes [utf8.RuneSelf]charClass) {
+	for r := range classes {
+		classes[r] = unicodeClassOf(rune(r))
+	}
+	return classes
+}()
+
+func classOf(r rune) charClass {
+	if r < utf8.RuneSelf {
+		return asciiClasses[r]
+	}
+	return unicodeClassOf(r)
+}
+
+func unicodeClassOf(r rune) charClass {
+	switch {
+	case unicode.IsSpace(r):
+		return charWhite
+	case strings.ContainsRune(delimiterChars, r):
+		return charDelimiter
+	case unicode.IsLower(r):
+		return charLower
+	case unicode.IsUpper(r):
+		return charUpper
+	case unicode.IsLetter(r):
+		return charLetter
+	case unicode.IsDigit(r):
+		return charNumber
+	}
+	return charNonWord
+}
+
+// bonusFor returns the bonus of a character of class cur that follows a
+// character of class prev.
+func bonusFor(prev, cur charClass) int {
+	if cur > charDelimiter {
+		switch prev {
+		case charWhite:
+			return bonusBoundaryWhite
+		case charDelimiter:
+			return bonusBoundaryDelimiter
+		case charNonWord:
+			return bonusBoundary
+		}
+	}
+	if prev == charLower && cur == charUpper || prev != charNumber && cur == charNumber {
+		return bonusCamel123
+	}
+	switch cur {
+	case charNonWord, charDelimiter:
+		return bonusNonWord
+	case charWhite:
+		return bonusBoundaryWhite
+	}
+	return 0
+}
+
+// charBonuses returns the bonus of matching each character of text.
+func charBonuses(text []rune) []int {
+	bonus := make([]int, len(text))
+	prev := charWhite
+	for i, r := range text {
+		class := classOf(r)
+		bonus[i] = bonusFor(prev, class)
+		prev = class
+	}
+	return bonus
+}
+
+// scoreAt scores a match of title at the given sorted rune indexes.
+func scoreAt(title string, positions []int) int {
+	return scorePositions(positions, charBonuses([]rune(title)))
+}
+
+// acronymMatch reports whether the case folded pattern matches characters of
+// title that each start a word or a camelCase hump, like "gcm" in
+// "git-commit-msg". Case is ignored.
+func acronymMatch(title string, pattern []rune) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	j := 0
+	prev := charWhite
+	for _, r := range title {
+		class := classOf(r)
+		if bonusFor(prev, class) >= bonusCamel123 && model.FoldRune(r) == pattern[j] {
+			if j++; j == len(pattern) {
+				return true
+			}
+		}
+		prev = class
+	}
+	return false
+}
+
+// scoreFuzzy matches the characters of query in order in title and returns
+// the score of the best match with the matched rune indexes of title.
+func scoreFuzzy(title, query string, ignoreCase bool) (int, []int, bool) {
+	text, pattern := []rune(title), []rune(query)
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	bonus := charBonuses(text)
+	if ignoreCase {
+		for i, r := range text {
+			text[i] = model.FoldRune(r)
+		}
+		for i, r := range pattern {
+			pattern[i] = model.FoldRune(r)
+		}
+	}
+
+	// the match lies between the first occurrence of the first character
+	// and the last occurrence of the last one
+	first, last := -1, -1
+	j := 0
+	for i, r := range text {
+		if r == pattern[j] {
+			if j == 0 {
+				first = i
+			}
+			if j++; j == len(pattern) {
+				break
+			}
+		}
+	}
+	if j < len(pattern) {
+		return 0, nil, false
+	}
+	for i := len(text) - 1; i >= first; i-- {
+		if text[i] == pattern[len(pattern)-1] {
+			last = i
+			break
+		}
+	}
+	text, bonus = text[first:last+1], bonus[first:last+1]
+
+	var positions []int
+	if len(text)*len(pattern) > maxScoredCells {
+		positions = shortestMatch(text, pattern)
+	} else {
+		positions = bestMatch(text, pattern, bonus)
+	}
+	score := scorePositions(positions, bonus)
+	for i := range positions {
+		positions[i] += first
+	}
+	return score, positions, true
+}
+
+// bestMatch returns the positions of the highest scoring match of pattern
+// in text, which must contain it.
+func bestMatch(text, pattern []rune, bonus []int) []int {
+	n, m := len(text), len(pattern)
+	const none = math.MinInt32
+	// score[j*n+i] is the best score of matching pattern[:j+1] with
+	// pattern[j] at text[i], from[j*n+i] is where pattern[j-1] went and
+	// chunk[j*n+i] is the bonus of the first character of the consecutive
+	// run ending there.
+	cells := make([]int32, 3*n*m)
+	score, from, chunk := cells[:n*m], cells[n*m:2*n*m], cells[2*n*m:]
+	for j := 0; j < m; j++ {
+		row := j * n
+		// gap is the best score of pattern[:j] ending before i-1, with the
+		// gap up to i-1 paid for, and gapFrom is where it ended
+		gap, gapFrom := int32(none), int32(-1)
+		for i := 0; i < n; i++ {
+			if j > 0 && i >= 2 {
+				if gap != none {
+					gap += scoreGapExtension
+				}
+				if s := score[row-n+i-2]; s != none && s+scoreGapStart > gap {
+					gap, gapFrom = s+scoreGapStart, int32(i-2)
+				}
+			}
+			score[row+i] = none
+			if text[i] != pattern[j] {
+				continue
+			}
+			b := int32(bonus[i])
+			if j == 0 {
+				score[row+i] = scoreMatch + b*bonusFirstCharMultiplier
+				from[row+i], chunk[row+i] = -1, b
+				continue
+			}
+			if i == 0 {
+				continue
+			}
+			if s := score[row-n+i-1]; s != none {
+				cb := chunk[row-n+i-1]
+				if b >= bonusBoundary && b > cb {
+					cb = b
+				}
+				s += scoreMatch + max(b, cb, bonusConsecutive)
+				score[row+i], from[row+i], chunk[row+i] = s, int32(i-1), cb
+			}
+			if gap != none && gap+scoreMatch+b > score[row+i] {
+				score[row+i], from[row+i], chunk[row+i] = gap+scoreMatch+b, gapFrom, b
+			}
+		}
+	}
+
+	end, best := -1, int32(none)
+	for i := 0; i < n; i++ {
+		if s := score[(m-1)*n+i]; s != none && s > best {
+			end, best = i, s
+		}
+	}
+	positions := make([]int, m)
+	for j := m - 1; j >= 0; j-- {
+		positions[j] = end
+		end = int(from[j*n+end])
+	}
+	return positions
+}
+
+// shortestMatch returns the positions of a match of pattern in text, which
+// must contain it, in the shortest window ending at its leftmost end.
+func shortestMatch(text, pattern []rune) []int {
+	end, j := 0, 0
+	for ; j < len(pattern); end++ {
+		if text[end] == pattern[j] {
+			j++
+		}
+	}
+	positions := make([]int, len(pattern))
+	for i := end - 1; j > 0; i-- {
+		if text[i] == pattern[j-1] {
+			j--
+			positions[j] = i
+		}
+	}
+	return positions
+}
+
+// scorePositions scores a match at the given sorted positions.
+func scorePositions(positions []int, bonus []int) int {
+	score, chunkBonus := 0, 0
+	for k, pos := range positions {
+		b := bonus[pos]
+		switch {
+		case k == 0:
+			score += scoreMatch + b*bonusFirstCharMultiplier
+			chunkBonus = b
+			continue
+		case pos == positions[k-1]+1:
+			if b >= bonusBoundary && b > chunkBonus {
+				chunkBonus = b
+			}
+			b = max(b, chunkBonus, bonusConsecutive)
+		default:
+			score += scoreGapStart + (pos-positions[k-1]-2)*scoreGapExtension
+			chunkBonus = b
+		}
+		score += scoreMatch + b
+	}
+	return score
+}
+
+// positionRanges converts sorted rune indexes into merged match ranges.
+func positionRanges(positions []int) []model.MatchRange {
+	ranges := make([]model.MatchRange, 0, len(positions))
+	for _, pos := range positions {
+		if n := len(ranges); n > 0 && ranges[n-1].End == pos {
+			ranges[n-1].End++
+			continue
+		}
+		ranges = append(ranges, model.MatchRange{Start: pos, End: pos + 1})
+	}
+	return ranges
+}
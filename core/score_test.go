@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreFuzzy(t *testing.T) {
+	t.Run("no match", func(t *testing.T) {
+		_, _, ok := scoreFuzzy("magic-config", "gcm", true)
+		assert.False(t, ok)
+	})
+
+	t.Run("prefers word starts", func(t *testing.T) {
+		_, positions, ok := scoreFuzzy("git-commit-msg", "gcm", true)
+		require.True(t, ok)
+		assert.Equal(t, []int{0, 4, 11}, positions)
+	})
+
+	t.Run("camel case humps", func(t *testing.T) {
+		_, positions, ok := scoreFuzzy("openFileDialog", "ofd", true)
+		require.True(t, ok)
+		assert.Equal(t, []int{0, 4, 8}, positions)
+	})
+
+	t.Run("path separators beat plain characters", func(t *testing.T) {
+		sep, _, _ := scoreFuzzy("src/main.go", "m", true)
+		plain, _, _ := scoreFuzzy("domain.go", "m", true)
+		assert.Greater(t, sep, plain)
+	})
+
+	t.Run("prefix beats word start", func(t *testing.T) {
+		prefix, _, _ := scoreFuzzy("config", "con", true)
+		word, _, _ := scoreFuzzy("my-config", "con", true)
+		assert.Greater(t, prefix, word)
+	})
+
+	t.Run("gaps cost", func(t *testing.T) {
+		near, _, _ := scoreFuzzy("abxc", "abc", true)
+		far, _, _ := scoreFuzzy("abxxxxc", "abc", true)
+		assert.Greater(t, near, far)
+	})
+
+	t.Run("case", func(t *testing.T) {
+		_, _, ok := scoreFuzzy("Makefile", "mk", false)
+		assert.False(t, ok)
+		_, _, ok = scoreFuzzy("Makefile", "mk", true)
+		assert.True(t, ok)
+	})
+
+	t.Run("long titles", func(t *testing.T) {
+		title := "a"
+		for len(title) < maxScoredCells {
+			title += title
+		}
+		score, positions, ok := scoreFuzzy(title+"bc", "abc", true)
+		require.True(t, ok)
+		assert.Equal(t, []int{len(title) - 1, len(title), len(title) + 1}, positions)
+		assert.Positive(t, score)
+	})
+}
+
+func TestFuzzySearchScoring(t *testing.T) {
+	items := strToItems(&[]string{"magic-config", "git-commit-msg", "gcm-helper"})
+
+	t.Run("ranks by score", func(t *testing.T) {
+		res := SearchMethods["fuzzy"](context.Background(), items, "gcm", false, 0)
+		assert.Equal(t, []string{"gcm-helper", "git-commit-msg"}, itemsToStr(res))
+		for _, item := range res {
+			assert.Positive(t, item.Score)
+		}
+		assert.Equal(t, []model.MatchRange{{Start: 0, End: 1}, {Start: 4, End: 5}, {Start: 11, End: 12}}, res[1].Matches)
+	})
+
+	t.Run("word starts beat scattered characters", func(t *testing.T) {
+		res := FuzzySearchBrute1(context.Background(), strToItems(&[]string{"magic-compiler", "git-commit-msg"}), "gcm", false, 0)
+		assert.Equal(t, []string{"git-commit-msg", "magic-compiler"}, itemsToStr(res))
+	})
+
+	t.Run("preserve order", func(t *testing.T) {
+		res := FuzzySearchBrute1(context.Background(), strToItems(&[]string{"magic-compiler", "git-commit-msg"}), "gcm", true, 0)
+		assert.Equal(t, []string{"magic-compiler", "git-commit-msg"}, itemsToStr(res))
+	})
+
+	t.Run("subquery scores add up", func(t *testing.T) {
+		single := SearchMethods["fuzzy"](context.Background(), items, "git", false, 0)
+		both := SearchMethods["fuzzy"](context.Background(), items, "git msg", false, 0)
+		require.Len(t, single, 1)
+		require.Len(t, both, 1)
+		assert.Greater(t, both[0].Score, single[0].Score)
+	})
+
+	t.Run("one and several terms rank by the same score", func(t *testing.T) {
+		items := strToItems(&[]string{"xxtodayxx", "to---day"})
+		res := FuzzySearchBrute(context.Background(), items, "today", false, 0)
+		require.Len(t, res, 2)
+		assert.Equal(t, scoreAt("xxtodayxx", []int{2, 3, 4, 5, 6})+bonusSubstring, res[1].Score, "substrings earn a bonus")
+		assert.Greater(t, res[0].Score, res[1].Score)
+
+		// several terms add up the same scores, bonuses included
+		day := FuzzySearchBrute(context.Background(), items, "day", false, 0)
+		multi := SearchWithSeparator(" ", FuzzySearchBrute)(context.Background(), items, "today day", false, 0)
+		require.Len(t, multi, 2)
+		scores := map[string]int{}
+		for _, item := range append(res, day...) {
+			scores[item.Title] += item.Score
+		}
+		for _, item := range multi {
+			assert.Equal(t, scores[item.Title], item.Score, item.Title)
+		}
+		assert.GreaterOrEqual(t, multi[0].Score, multi[1].Score)
+	})
+}
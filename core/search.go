@@ -1,50 +1,188 @@
 package core
 
 import (
+	"context"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/hamidzr/gmenu/model"
 	"github.com/sahilm/fuzzy"
 )
 
 // SearchMethod how to search for items given a keyword.
-type SearchMethod func(items []model.MenuItem, query string,
+// An empty query matches every item. Returned items carry the title ranges
+// matched by the query in MenuItem.Matches. A search may stop early once ctx
+// is done and its result is then meaningless.
+type SearchMethod func(ctx context.Context, items []model.MenuItem, query string,
 	preserveOrder bool, limit int) []model.MenuItem
 
 // IsDirectMatch checks if a string contains a keyword.
 func IsDirectMatch(s, keyword string, smartMatch bool) bool {
-	if smartMatch && strings.ToLower(keyword) != keyword {
-		return strings.Contains(s, keyword)
+	_, ok := directMatch(s, keyword, smartMatch)
+	return ok
+}
+
+// directMatch returns the range of the first occurrence of keyword in s.
+func directMatch(s, keyword string, smartMatch bool) (model.MatchRange, bool) {
+	if !smartMatch || model.FoldCase(keyword) == keyword {
+		s, keyword = model.FoldCase(s), model.FoldCase(keyword)
+	}
+	idx := strings.Index(s, keyword)
+	if idx < 0 {
+		return model.MatchRange{}, false
+	}
+	start := utf8.RuneCountInString(s[:idx])
+	return model.MatchRange{Start: start, End: start + utf8.RuneCountInString(keyword)}, true
+}
+
+// textQuery is a query prepared for matching prepared titles.
+type textQuery struct {
+	text  string
+	lower string
+	// caseSensitive is set under smart case when the query has upper case
+	// letters.
+	caseSensitive bool
+	// chars is the CharBitmap of lower, to skip items missing a character.
+	chars uint64
+}
+
+func newTextQuery(query string, smartCase bool) textQuery {
+	lower := model.FoldCase(query)
+	return textQuery{
+		text:          query,
+		lower:         lower,
+		caseSensitive: smartCase && lower != query,
+		chars:         model.CharBitmap(lower),
+	}
+}
+
+// operands returns the strings to compare: the prepared title and the query,
+// or their case folded forms when case is ignored.
+func (q textQuery) operands(text *model.SearchText) (string, string) {
+	if q.caseSensitive {
+		return text.Text, q.text
 	}
-	return strings.Contains(strings.ToLower(s), strings.ToLower(keyword))
+	return text.Lower, q.lower
+}
+
+// contains returns the range of the first occurrence of the query in title.
+func (q textQuery) contains(text *model.SearchText) (model.MatchRange, bool) {
+	if !text.MayContain(q.chars) {
+		return model.MatchRange{}, false
+	}
+	s, sub := q.operands(text)
+	idx := strings.Index(s, sub)
+	if idx < 0 {
+		return model.MatchRange{}, false
+	}
+	start := idx
+	if text.Runes != nil {
+		start = utf8.RuneCountInString(s[:idx])
+	}
+	return model.MatchRange{Start: start, End: start + utf8.RuneCountInString(sub)}, true
 }
 
 func fuzzyContainsConsec(s, query string, ignoreCase bool, minConsecutive int) bool {
+	_, ok := fuzzyMatchConsec(s, query, ignoreCase, minConsecutive)
+	return ok
+}
+
+// fuzzyMatchConsec matches query against s, requiring the first
+// minConsecutive characters to be adjacent. It returns the matched ranges.
+func fuzzyMatchConsec(s, query string, ignoreCase bool, minConsecutive int) ([]model.MatchRange, bool) {
 	if ignoreCase {
-		s, query = strings.ToLower(s), strings.ToLower(query)
+		s, query = model.FoldCase(s), model.FoldCase(query)
 	}
+	positions, ok := consecutivePositions(s, query, minConsecutive)
+	return positionRanges(positions), ok
+}
 
-	minConsecutive = min(max(minConsecutive, 1), len(query))
-	// CHECK: or do we skip if len is shorter that minConsecutive?
-
-	// Iterate through 's' to find at least 'minConsecutive' consecutive matching characters
-	for i := 0; i <= len(s)-minConsecutive; i++ {
-		if s[i:i+minConsecutive] == query[0:minConsecutive] {
+// consecutivePositions returns the rune indexes of s matching the runes of
+// query in order, the first minConsecutive of them adjacent.
+func consecutivePositions(s, query string, minConsecutive int) ([]int, bool) {
+	if query == "" {
+		return nil, true
+	}
+	// head is the part of query that has to match consecutively
+	head := query
+	n := 0
+	for i := range query {
+		if n == max(minConsecutive, 1) {
+			head = query[:i]
+			break
+		}
+		n++
+	}
+	pos := 0
+	for i := range s {
+		if strings.HasPrefix(s[i:], head) {
 			// Found the starting point with 'minConsecutive' matching characters
-			queryIndex := minConsecutive
+			positions := make([]int, 0, len(query))
+			for k := 0; k < n; k++ {
+				positions = append(positions, pos+k)
+			}
 			// Continue matching the rest of the query (non-consecutively)
-			for j := i + minConsecutive; j < len(s) && queryIndex < len(query); j++ {
-				if s[j] == query[queryIndex] {
-					queryIndex++
+			rest := query[len(head):]
+			j := pos + n
+			for _, r := range s[i+len(head):] {
+				if rest == "" {
+					break
+				}
+				if q, size := utf8.DecodeRuneInString(rest); r == q {
+					positions = append(positions, j)
+					rest = rest[size:]
 				}
+				j++
 			}
-			if queryIndex == len(query) {
-				return true
+			if rest == "" {
+				return positions, true
 			}
 		}
+		pos++
+	}
+	return nil, false
+}
+
+// matchRanges converts sorted byte offsets of matched characters in s into
+// merged rune ranges.
+func matchRanges(s string, offsets []int) []model.MatchRange {
+	ranges := make([]model.MatchRange, 0, len(offsets))
+	for _, offset := range offsets {
+		if offset < 0 || offset >= len(s) {
+			continue
+		}
+		idx := utf8.RuneCountInString(s[:offset])
+		// bytes of a multi-byte character map to the same rune index
+		if n := len(ranges); n > 0 && ranges[n-1].End >= idx {
+			ranges[n-1].End = max(ranges[n-1].End, idx+1)
+			continue
+		}
+		ranges = append(ranges, model.MatchRange{Start: idx, End: idx + 1})
+	}
+	return ranges
+}
+
+// mergeMatchRanges combines two range lists into sorted, non-overlapping ranges.
+func mergeMatchRanges(a, b []model.MatchRange) []model.MatchRange {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	all := append(append(make([]model.MatchRange, 0, len(a)+len(b)), a...), b...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Start < all[j].Start })
+	merged := all[:1]
+	for _, r := range all[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
 	}
-	return false
+	return merged
 }
 
 func applyLimit(matches []model.MenuItem, limit int) []model.MenuItem {
@@ -55,56 +193,131 @@ func applyLimit(matches []model.MenuItem, limit int) []model.MenuItem {
 }
 
 // DirectSearch matches items directly to a keyword.
-func DirectSearch(items []model.MenuItem, keyword string, _ bool, limit int) []model.MenuItem {
-	matches := make([]model.MenuItem, 0)
-	for _, item := range items {
-		if IsDirectMatch(item.ComputedTitle(), keyword, true) {
-			matches = append(matches, item)
-		}
+func DirectSearch(ctx context.Context, items []model.MenuItem, keyword string, _ bool, limit int) []model.MenuItem {
+	if keyword == "" {
+		return applyLimit(items, limit)
 	}
+	q := newTextQuery(keyword, true)
+	matches := matchItems(ctx, items, func(item *model.MenuItem) (model.MenuItem, bool) {
+		r, ok := q.contains(item.SearchText())
+		if !ok {
+			return model.MenuItem{}, false
+		}
+		matched := *item
+		matched.Matches = []model.MatchRange{r}
+		return matched, true
+	})
 	return applyLimit(matches, limit)
 }
 
-func fuzzySearchBruteConsec(items []model.MenuItem, keyword string, limit int, minConsecutive int) []model.MenuItem {
+// fuzzySearchBruteConsec keeps the items that contain keyword or fuzzy match
+// it, either with minConsecutive adjacent characters or with every matched
+// character starting a word. Matches are ranked by their score, which is
+// written to MenuItem.Score and includes bonusSubstring for items containing
+// keyword. Items with equal scores keep their input order.
+func fuzzySearchBruteConsec(ctx context.Context, items []model.MenuItem, keyword string, preserveOrder bool, limit int, minConsecutive int) []model.MenuItem {
 	if keyword == "" {
 		return items
 	}
-	directMatches := make([]model.MenuItem, 0)
-	fuzzyMatches := make([]model.MenuItem, 0)
-	for _, item := range items {
-		title := item.ComputedTitle()
-		if IsDirectMatch(title, keyword, true) {
-			directMatches = append(directMatches, item)
-		} else if fuzzyContainsConsec(title, keyword, true, minConsecutive) {
-			fuzzyMatches = append(fuzzyMatches, item)
+	q := newTextQuery(keyword, true)
+	pattern := []rune(q.lower)
+	matches := matchItems(ctx, items, func(item *model.MenuItem) (model.MenuItem, bool) {
+		text := item.SearchText()
+		if !text.MayContain(q.chars) {
+			return model.MenuItem{}, false
 		}
+		title := text.Text
+		if r, ok := q.contains(text); ok {
+			positions := make([]int, 0, r.End-r.Start)
+			for pos := r.Start; pos < r.End; pos++ {
+				positions = append(positions, pos)
+			}
+			matched := *item
+			matched.Score = scoreAt(title, positions) + bonusSubstring
+			matched.Matches = []model.MatchRange{r}
+			return matched, true
+		}
+		if !fuzzyContainsConsec(text.Lower, q.lower, false, minConsecutive) && !acronymMatch(title, pattern) {
+			return model.MenuItem{}, false
+		}
+		score, positions, ok := scoreFuzzy(title, keyword, true)
+		matched := *item
+		matched.Score = score
+		matched.Matches = positionRanges(positions)
+		return matched, ok
+	})
+	if !preserveOrder {
+		sortByScore(matches)
 	}
-	return applyLimit(append(directMatches, fuzzyMatches...), limit)
+	return applyLimit(matches, limit)
 }
 
-// FuzzySearchBrute is a brute force fuzzy search.
-// Direct matches are prioritized over fuzzy matches.
-func FuzzySearchBrute1(items []model.MenuItem, keyword string, _ bool, limit int) []model.MenuItem {
-	return fuzzySearchBruteConsec(items, keyword, limit, 1)
+// sortByScore orders items by descending score, keeping the order of equal ones.
+func sortByScore(items []model.MenuItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
 }
 
-// FuzzySearchBrute is a brute force fuzzy search.
-// Direct matches are prioritized over fuzzy matches.
+// FuzzySearchBrute1 is a brute force fuzzy search ranked by match score.
+func FuzzySearchBrute1(ctx context.Context, items []model.MenuItem, keyword string, preserveOrder bool, limit int) []model.MenuItem {
+	return fuzzySearchBruteConsec(ctx, items, keyword, preserveOrder, limit, 1)
+}
+
+// FuzzySearchBrute is a brute force fuzzy search ranked by match score.
 // A minimum of 2 consecutive characters are required for a fuzzy match.
-func FuzzySearchBrute(items []model.MenuItem, keyword string, _ bool, limit int) []model.MenuItem {
-	return fuzzySearchBruteConsec(items, keyword, limit, 2)
+func FuzzySearchBrute(ctx context.Context, items []model.MenuItem, keyword string, preserveOrder bool, limit int) []model.MenuItem {
+	return fuzzySearchBruteConsec(ctx, items, keyword, preserveOrder, limit, 2)
 }
 
-// SearchWithSeparator breaks down the keyword into subqueries.
+// SearchWithSeparator breaks down the keyword into subqueries that all have
+// to match. The score of an item is the sum of its subquery scores and, with
+// more than one subquery, the items are ranked by it.
 func SearchWithSeparator(separator string, searchMethod SearchMethod) SearchMethod {
-	search := func(items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
+	search := func(ctx context.Context, items []model.MenuItem, query string, preserveOrder bool, limit int) []model.MenuItem {
 		// split keyword into words
-		subQs := strings.Split(query, separator)
-		matchedSubset := items // copy?
-		// matches := make([]model.MenuItem, 0)
-		for _, subQ := range subQs {
-			// logrus.Trace("Subquery: ", subQ, "subset", matchedSubset)
-			matchedSubset = searchMethod(matchedSubset, subQ, false, 0)
+		subQs := make([]string, 0)
+		for _, subQ := range strings.Split(query, separator) {
+			if subQ != "" {
+				subQs = append(subQs, subQ)
+			}
+		}
+		if len(subQs) == 0 {
+			return applyLimit(items, limit)
+		}
+		matchedSubset := items
+		for n, subQ := range subQs {
+			type state struct {
+				matches []model.MatchRange
+				score   int
+			}
+			if n == 0 {
+				matchedSubset = searchMethod(ctx, matchedSubset, subQ, preserveOrder, 0)
+				continue
+			}
+			// score the subquery on its own, methods that don't score keep
+			// what they're given
+			previous := make(map[string]state, len(matchedSubset))
+			subset := make([]model.MenuItem, len(matchedSubset))
+			for i, item := range matchedSubset {
+				previous[item.Key()] = state{item.Matches, item.Score}
+				item.Score = 0
+				subset[i] = item
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			matchedSubset = searchMethod(ctx, subset, subQ, preserveOrder, 0)
+			// keep the ranges and scores of earlier subqueries
+			for i := range matchedSubset {
+				item := &matchedSubset[i]
+				prev := previous[item.Key()]
+				item.Matches = mergeMatchRanges(prev.matches, item.Matches)
+				item.Score += prev.score
+			}
+		}
+		if len(subQs) > 1 && !preserveOrder {
+			sortByScore(matchedSubset)
 		}
 		return applyLimit(matchedSubset, limit)
 	}
@@ -137,12 +350,28 @@ func filterOutUnlikelyMatches(matches []fuzzy.Match) []fuzzy.Match {
 }
 
 // FuzzySearch fuzzy matches items to a keyword and sorts them by score.
-func FuzzySearch(items []model.MenuItem, keyword string,
+func FuzzySearch(ctx context.Context, items []model.MenuItem, keyword string,
 	preserveOrder bool, limit int,
 ) []model.MenuItem {
-	entries := make([]string, len(items))
-	for i, item := range items {
-		entries[i] = item.ComputedTitle()
+	if keyword == "" {
+		return applyLimit(items, limit)
+	}
+	type candidate struct {
+		item model.MenuItem
+		text string
+	}
+	chars := model.CharBitmap(model.FoldCase(keyword))
+	candidates := matchItems(ctx, items, func(item *model.MenuItem) (candidate, bool) {
+		text := item.SearchText()
+		if !text.MayContain(chars) {
+			return candidate{}, false
+		}
+		return candidate{*item, text.Text}, true
+	})
+	items = make([]model.MenuItem, len(candidates))
+	entries := make([]string, len(candidates))
+	for i, c := range candidates {
+		items[i], entries[i] = c.item, c.text
 	}
 
 	matches := fuzzy.Find(keyword, entries)
@@ -153,23 +382,19 @@ func FuzzySearch(items []model.MenuItem, keyword string,
 		}
 		return matches[i].Score > matches[j].Score
 	})
-	matches = matches[:min(limit, len(matches))]
+	if limit > 0 {
+		matches = matches[:min(limit, len(matches))]
+	}
 	matches = filterOutUnlikelyMatches(matches)
-	if !preserveOrder {
-		for _, match := range matches {
-			results = append(results, items[match.Index])
-		}
-		return results
+	if preserveOrder {
+		sort.Slice(matches, func(i, j int) bool {
+			return matches[i].Index < matches[j].Index
+		})
 	}
-	matchIndices := make([]int, 0)
 	for _, match := range matches {
-		matchIndices = append(matchIndices, match.Index)
-	}
-	sort.Slice(matchIndices, func(i, j int) bool {
-		return matchIndices[i] < matchIndices[j]
-	})
-	for _, ogIndex := range matchIndices {
-		results = append(results, items[ogIndex])
+		item := items[match.Index]
+		item.Matches = matchRanges(match.Str, match.MatchedIndexes)
+		results = append(results, item)
 	}
 
 	return results
@@ -182,4 +407,16 @@ var SearchMethods = map[string]SearchMethod{
 	"fuzzy1":  FuzzySearch,
 	"fuzzy3":  FuzzySearchBrute,
 	"default": SearchWithSeparator(" ", FuzzySearchBrute),
+	// extended adds fzf's query operators to the fuzzy search
+	"extended": ExtendedSearch(FuzzySearchBrute),
+}
+
+// narrowingSearchMethods names the search methods whose matches for a query
+// are among the matches for any prefix of it. Menus using them search only
+// the previous matches while the query grows.
+var narrowingSearchMethods = map[string]bool{
+	"direct":  true,
+	"fuzzy":   true,
+	"fuzzy3":  true,
+	"default": true,
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"testing"
 	"unicode/utf8"
 
@@ -104,7 +105,7 @@ func TestDirectSearchPerformance(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results := DirectSearch(largeDataset, tt.keyword, false, tt.limit)
+			results := DirectSearch(context.Background(), largeDataset, tt.keyword, false, tt.limit)
 
 			assert.GreaterOrEqual(t, len(results), tt.minResults)
 			assert.LessOrEqual(t, len(results), tt.maxResults)
@@ -164,7 +165,7 @@ func TestFuzzySearchBruteRobustness(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Test should not panic
 			assert.NotPanics(t, func() {
-				results := fuzzySearchBruteConsec(testItems, tt.query, 10, tt.minConsec)
+				results := fuzzySearchBruteConsec(context.Background(), testItems, tt.query, false, 10, tt.minConsec)
 
 				if tt.shouldFind {
 					// Should find at least something for reasonable queries
@@ -249,7 +250,7 @@ func TestSearchWithSeparatorAdvanced(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			searchMethod := SearchWithSeparator(tt.separator, DirectSearch)
-			results := searchMethod(testItems, tt.query, false, 0)
+			results := searchMethod(context.Background(), testItems, tt.query, false, 0)
 
 			var resultTitles []string
 			for _, result := range results {
@@ -272,6 +273,7 @@ func TestSearchMethodsRegistration(t *testing.T) {
 		"fuzzy1",
 		"fuzzy3",
 		"default",
+		"extended",
 	}
 
 	for _, methodName := range expectedMethods {
@@ -288,7 +290,7 @@ func TestSearchMethodsRegistration(t *testing.T) {
 			}
 
 			assert.NotPanics(t, func() {
-				results := method(testItems, "test", false, 10)
+				results := method(context.Background(), testItems, "test", false, 10)
 				assert.NotNil(t, results)
 			})
 		})
@@ -349,9 +351,9 @@ func TestSearchMethodConsistency(t *testing.T) {
 	for methodName, method := range SearchMethods {
 		t.Run(methodName, func(t *testing.T) {
 			// Test consistency across multiple calls
-			results1 := method(testItems, query, false, 10)
-			results2 := method(testItems, query, false, 10)
-			results3 := method(testItems, query, false, 10)
+			results1 := method(context.Background(), testItems, query, false, 10)
+			results2 := method(context.Background(), testItems, query, false, 10)
+			results3 := method(context.Background(), testItems, query, false, 10)
 
 			assert.Equal(t, len(results1), len(results2))
 			assert.Equal(t, len(results2), len(results3))
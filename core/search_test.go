@@ -1,7 +1,9 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hamidzr/gmenu/model"
@@ -73,7 +75,7 @@ func TestWithSeparators(t *testing.T) {
 
 	for _, tc := range testCases {
 		items := strToItems(tc.items)
-		res := tc.searchMethod(items, tc.query, false, 3)
+		res := tc.searchMethod(context.Background(), items, tc.query, false, 3)
 		resStrs := itemsToStr(res)
 		assert.Equal(t, *tc.expectedItems, resStrs, "query: %s", tc.query)
 		// assert.ElementsMatch(t, *tc.expectedItems, resStrs, itemStrs, tc.query, resStrs)
@@ -116,7 +118,7 @@ func TestFuzzy(t *testing.T) {
 		for _, tc := range testCases {
 			fmt.Println("test case", tc)
 			itemStrs := strToItems(tc.items)
-			res := FuzzySearchBrute1(itemStrs, tc.query, false, 3)
+			res := FuzzySearchBrute1(context.Background(), itemStrs, tc.query, false, 3)
 			resStrs := itemsToStr(res)
 
 			if tc.expectedItems != nil {
@@ -156,7 +158,7 @@ func TestFuzzySearch(t *testing.T) {
 		{Title: "banana"},
 		{Title: "apricot"},
 	}
-	results := FuzzySearch(items, "ap", false, 10)
+	results := FuzzySearch(context.Background(), items, "ap", false, 10)
 	expected := []string{"apple", "apricot"}
 	var titles []string
 	for _, item := range results {
@@ -246,9 +248,10 @@ func TestFuzzySearchBrute(t *testing.T) {
 			},
 			query: "today",
 			limit: 10,
+			// the word start hits outscore the substring inside a word
 			expectedItems: []string{
-				"xxtodayxx",
 				"to---day",
+				"xxtodayxx",
 			},
 		},
 	}
@@ -256,9 +259,168 @@ func TestFuzzySearchBrute(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			items := strToItems(&tc.items)
-			results := fuzzySearchBruteConsec(items, tc.query, tc.limit, 2)
+			results := fuzzySearchBruteConsec(context.Background(), items, tc.query, false, tc.limit, 2)
 			resultTitles := itemsToStr(results)
 			assert.Equal(t, tc.expectedItems, resultTitles)
 		})
 	}
 }
+
+func TestSearchMatchRanges(t *testing.T) {
+	items := []model.MenuItem{{Title: "Hello World"}, {Title: "naïve café"}}
+	tests := []struct {
+		name     string
+		method   SearchMethod
+		query    string
+		expected map[string][]model.MatchRange
+	}{
+		{
+			name:   "direct",
+			method: DirectSearch,
+			query:  "world",
+			expected: map[string][]model.MatchRange{
+				"Hello World": {{Start: 6, End: 11}},
+			},
+		},
+		{
+			name:   "direct counts runes",
+			method: DirectSearch,
+			query:  "café",
+			expected: map[string][]model.MatchRange{
+				"naïve café": {{Start: 6, End: 10}},
+			},
+		},
+		{
+			name:   "consecutive fuzzy",
+			method: FuzzySearchBrute,
+			query:  "hewd",
+			expected: map[string][]model.MatchRange{
+				"Hello World": {{Start: 0, End: 2}, {Start: 6, End: 7}, {Start: 10, End: 11}},
+			},
+		},
+		{
+			name:   "separated subqueries are merged",
+			method: SearchMethods["fuzzy"],
+			query:  "wor hel",
+			expected: map[string][]model.MatchRange{
+				"Hello World": {{Start: 0, End: 3}, {Start: 6, End: 9}},
+			},
+		},
+		{
+			name:   "sahilm fuzzy",
+			method: FuzzySearch,
+			query:  "hw",
+			expected: map[string][]model.MatchRange{
+				"Hello World": {{Start: 0, End: 1}, {Start: 6, End: 7}},
+			},
+		},
+		{
+			name:   "empty query has no ranges",
+			method: SearchMethods["fuzzy"],
+			query:  "",
+			expected: map[string][]model.MatchRange{
+				"Hello World": nil,
+				"naïve café":  nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := tt.method(context.Background(), items, tt.query, false, 0)
+			actual := make(map[string][]model.MatchRange, len(results))
+			for _, item := range results {
+				actual[item.Title] = item.Matches
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+	// the input items are left untouched
+	assert.Nil(t, items[0].Matches)
+}
+
+func TestSearchIndex(t *testing.T) {
+	titles := []string{"Hello World", "naïve café", "git-commit-msg", "src/main.go", "README.md", "alpha 2"}
+	plain := strToItems(&titles)
+	indexed := strToItems(&titles)
+	for i := range indexed {
+		indexed[i].Search = model.NewSearchText(indexed[i].ComputedTitle(), false)
+	}
+	queries := []string{"", "o", "wor", "World", "CAF", "gcm", "ma go", "xyz", "'main", "^src", ".md$", "!alpha", "2"}
+	for name, method := range SearchMethods {
+		for _, query := range queries {
+			expected := method(context.Background(), plain, query, false, 0)
+			actual := method(context.Background(), indexed, query, false, 0)
+			assert.Equal(t, itemsToStr(expected), itemsToStr(actual), "method %s, query %q", name, query)
+		}
+	}
+
+	t.Run("menus index their items", func(t *testing.T) {
+		m, err := newMenu(context.Background(), titles, "", DirectSearch, false, false, 0, model.ItemFormat{})
+		require.NoError(t, err)
+		m.appendItems(strToItems(&[]string{"appended"}))
+		for _, item := range m.items {
+			require.NotNil(t, item.Search, item.Title)
+			assert.Equal(t, strings.ToLower(item.ComputedTitle()), item.Search.Lower)
+		}
+	})
+}
+
+func TestUnicodeSearch(t *testing.T) {
+	titles := []string{"Müller.pdf", "Jürgen Straße", "ÄRGER", "فارسی سند", "آبان گزارش", "naïve café", "Σοφία"}
+	items := strToItems(&titles)
+
+	t.Run("multi-byte characters match whole", func(t *testing.T) {
+		// é and ï share their leading byte
+		assert.False(t, fuzzyContainsConsec("ï", "é", false, 1))
+		ranges, ok := fuzzyMatchConsec("naïve café", "ïé", false, 1)
+		require.True(t, ok)
+		assert.Equal(t, []model.MatchRange{{Start: 2, End: 3}, {Start: 9, End: 10}}, ranges)
+	})
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "ärger", expected: []string{"ÄRGER"}},
+		{query: "straße", expected: []string{"Jürgen Straße"}},
+		{query: "σοφια", expected: nil},
+		{query: "σοφία", expected: []string{"Σοφία"}},
+		{query: "فارسی", expected: []string{"فارسی سند"}},
+		{query: "muller", expected: nil},
+	}
+	for name, method := range SearchMethods {
+		for _, tt := range tests {
+			res := method(context.Background(), items, tt.query, false, 0)
+			assert.Equal(t, tt.expected, itemsToStrOrNil(res), "method %s, query %q", name, tt.query)
+		}
+	}
+
+	t.Run("ignore diacritics", func(t *testing.T) {
+		for name := range SearchMethods {
+			m, err := newMenu(context.Background(), titles, "", SearchMethods[name], false, true, 0, model.ItemFormat{})
+			require.NoError(t, err)
+			for query, expected := range map[string]string{
+				"muller": "Müller.pdf",
+				"müller": "Müller.pdf",
+				"σοφια":  "Σοφία",
+				"naive":  "naïve café",
+				"اب":     "آبان گزارش",
+			} {
+				m.Search(query)
+				require.NotEmpty(t, m.Filtered, "method %s, query %q", name, query)
+				assert.Equal(t, expected, m.Filtered[0].Title, "method %s, query %q", name, query)
+			}
+			m.Search("muller")
+			// ranges point at the runes of the original title
+			assert.Equal(t, []model.MatchRange{{Start: 0, End: 6}}, m.Filtered[0].Matches, name)
+		}
+	})
+}
+
+func itemsToStrOrNil(items []model.MenuItem) []string {
+	if len(items) == 0 {
+		return nil
+	}
+	return itemsToStr(items)
+}
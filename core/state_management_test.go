@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/test"
 	"github.com/hamidzr/gmenu/model"
 	"github.com/stretchr/testify/assert"
@@ -537,3 +538,122 @@ func TestConcurrentItemOperations(t *testing.T) {
 	gmenu.menu.itemsMutex.Unlock()
 	assert.True(t, found, "Initial item should still be present")
 }
+
+// TestStreamingItems tests appending items while the menu is loading
+func TestStreamingItems(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{NoNumericSelection: true})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	require.NoError(t, gmenu.SetupMenu(nil, ""))
+	gmenu.SetLoading(true)
+	// the loading item is listed until the first batch arrives but isn't counted
+	require.Eventually(t, func() bool {
+		return gmenu.matchCounterLabel() == "[0/0…]"
+	}, time.Second, 10*time.Millisecond)
+
+	gmenu.AppendItems([]string{"alpha", "beta"})
+	require.Eventually(t, func() bool {
+		return gmenu.matchCounterLabel() == "[2/2…]"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"alpha", "beta"}, itemsToStr(gmenu.menu.items), "the loading item is replaced")
+
+	// the selection survives new batches and duplicates are skipped
+	gmenu.menu.selectIndex(1)
+	gmenu.AppendItems([]string{"alpha", "gamma"})
+	gmenu.SetLoading(false)
+	require.Eventually(t, func() bool {
+		return gmenu.matchCounterLabel() == "[3/3]"
+	}, time.Second, 10*time.Millisecond)
+	gmenu.menu.itemsMutex.Lock()
+	defer gmenu.menu.itemsMutex.Unlock()
+	assert.Equal(t, "beta", gmenu.menu.Filtered[gmenu.menu.Selected].Title)
+}
+
+// TestLoadingPlaceholder tests that the loading item is never matched or
+// accepted
+func TestLoadingPlaceholder(t *testing.T) {
+	useFyneTestApp(t)
+	gmenu, err := NewGMenu(DirectSearch, &model.Config{NoNumericSelection: true})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+	require.NoError(t, gmenu.SetupMenu(nil, ""))
+	gmenu.SetLoading(true)
+
+	gmenu.menu.Search("lo")
+	gmenu.menu.itemsMutex.Lock()
+	assert.Equal(t, []model.MenuItem{model.LoadingItem}, gmenu.menu.Filtered, "the placeholder stays listed")
+	assert.Empty(t, gmenu.menu.Filtered[0].Matches, "the placeholder isn't matched")
+	gmenu.menu.itemsMutex.Unlock()
+	assert.Equal(t, 0, gmenu.MatchCount())
+	assert.Nil(t, gmenu.selectedItem())
+
+	// enter before the first batch doesn't accept the placeholder
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.False(t, gmenu.selectionFuse.IsBroken())
+	gmenu.handleItemClick(0)
+	assert.False(t, gmenu.selectionFuse.IsBroken(), "the placeholder can't be clicked")
+
+	// with custom selections the query is accepted instead
+	gmenu.config.AcceptCustomSelection = true
+	gmenu.ui.SearchEntry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	vals, err := gmenu.SelectedValues()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lo"}, itemsToStr(vals))
+}
+
+// TestReconfigure tests applying a new config to a running menu
+func TestReconfigure(t *testing.T) {
+	useFyneTestApp(t)
+	first := &model.Config{
+		Title:        "Daemon",
+		Prompt:       "first>",
+		SearchMethod: "direct",
+		MinWidth:     300,
+		MinHeight:    200,
+		Preview:      "echo {}",
+	}
+	gmenu, err := NewGMenu(DirectSearch, first)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		gmenu.previewer.Stop()
+		if gmenu.menuCancel != nil {
+			gmenu.menuCancel()
+		}
+	})
+
+	next := *first
+	next.Prompt = "second>"
+	next.SearchMethod = "fuzzy"
+	require.NoError(t, gmenu.Reconfigure(&next))
+	require.NoError(t, gmenu.SetupMenu([]string{"apple pie", "banana"}, "apie"))
+	assert.Equal(t, []string{"apple pie"}, itemsToStr(gmenu.menu.Filtered), "the fuzzy search method is used")
+	gmenu.safeUIUpdate(func() {
+		assert.Equal(t, "second>", gmenu.ui.SearchEntry.PlaceHolder)
+	})
+
+	// settings tied to the lock or the built UI are rejected, not dropped
+	for name, change := range map[string]func(*model.Config){
+		"menu id":          func(c *model.Config) { c.MenuID = "other" },
+		"preview":          func(c *model.Config) { c.Preview = "cat {}" },
+		"preview position": func(c *model.Config) { c.PreviewPosition = "bottom" },
+		"window size":      func(c *model.Config) { c.MinWidth = 400 },
+	} {
+		changed := next
+		change(&changed)
+		assert.Error(t, gmenu.Reconfigure(&changed), name)
+	}
+	assert.Equal(t, "fuzzy", gmenu.conf().SearchMethod, "a rejected config is not applied")
+
+	next.SearchMethod = "bogus"
+	assert.Error(t, gmenu.Reconfigure(&next))
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/hamidzr/gmenu/model"
 	"golang.org/x/term"
@@ -36,7 +38,7 @@ func ReadUserInputLive(cfg *model.Config, queryChan chan<- string) (string, erro
 	}()
 
 	reader := bufio.NewReader(os.Stdin)
-	input := []byte(cfg.InitialQuery)
+	input := []rune(cfg.InitialQuery)
 
 	fmt.Printf("\r%s%s", cfg.Prompt, string(input))
 	queryChan <- string(input)
@@ -52,7 +54,7 @@ func ReadUserInputLive(cfg *model.Config, queryChan chan<- string) (string, erro
 
 	go func() {
 		for {
-			char, err := reader.ReadByte()
+			char, _, err := reader.ReadRune()
 			if err != nil {
 				fmt.Println("\nError reading input:", err)
 				select {
@@ -74,11 +76,6 @@ func ReadUserInputLive(cfg *model.Config, queryChan chan<- string) (string, erro
 				default:
 				}
 				return
-			case char == 127 || char == 8:
-				if len(input) > 0 {
-					input = input[:len(input)-1]
-					queryChan <- string(input)
-				}
 			case char == 3:
 				fmt.Printf("\n%sInput cancelled\n", cfg.Prompt)
 				select {
@@ -90,9 +87,11 @@ func ReadUserInputLive(cfg *model.Config, queryChan chan<- string) (string, erro
 				default:
 				}
 				return
-			case char >= 32 && char <= 126:
-				input = append(input, char)
-				queryChan <- string(input)
+			default:
+				var changed bool
+				if input, changed = editInput(input, char); changed {
+					queryChan <- string(input)
+				}
 			}
 		}
 	}()
@@ -113,3 +112,19 @@ func ReadUserInputLive(cfg *model.Config, queryChan chan<- string) (string, erro
 		}
 	}
 }
+
+// editInput applies a character read from the terminal to input: backspace
+// deletes the last character and any other non-control character, in any
+// script, is appended. It reports whether input changed.
+func editInput(input []rune, char rune) ([]rune, bool) {
+	switch {
+	case char == 127 || char == 8:
+		if len(input) == 0 {
+			return input, false
+		}
+		return input[:len(input)-1], true
+	case char == utf8.RuneError || unicode.IsControl(char):
+		return input, false
+	}
+	return append(input, char), true
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/hamidzr/gmenu/model"
 	"github.com/stretchr/testify/assert"
@@ -360,3 +361,25 @@ func TestTerminalConfigDefaults(t *testing.T) {
 	assert.Equal(t, "Terminal prompt: ", config.Prompt)
 	assert.Equal(t, "initial", config.InitialQuery)
 }
+
+func TestEditInput(t *testing.T) {
+	var input []rune
+	for _, char := range "Müller فارسی‌" {
+		var changed bool
+		input, changed = editInput(input, char)
+		assert.True(t, changed)
+	}
+	assert.Equal(t, "Müller فارسی‌", string(input))
+
+	input, changed := editInput(input, 127)
+	assert.True(t, changed)
+	input, _ = editInput(input, 127)
+	assert.Equal(t, "Müller فارس", string(input))
+
+	for _, char := range []rune{27, 0, utf8.RuneError} {
+		_, changed = editInput(input, char)
+		assert.False(t, changed)
+	}
+	_, changed = editInput(nil, 8)
+	assert.False(t, changed)
+}
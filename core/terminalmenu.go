@@ -0,0 +1,813 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/store"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
+)
+
+// terminal escape sequences used to draw the menu.
+const (
+	ansiAltScreenOn  = "\x1b[?1049h"
+	ansiAltScreenOff = "\x1b[?1049l"
+	ansiHideCursor   = "\x1b[?25l"
+	ansiShowCursor   = "\x1b[?25h"
+	ansiHome         = "\x1b[H"
+	ansiClearLine    = "\x1b[2K"
+	ansiClearBelow   = "\x1b[J"
+	ansiReverse      = "\x1b[7m"
+	ansiReset        = "\x1b[0m"
+	ansiMatchOn      = "\x1b[1;36m"
+	ansiMatchOff     = "\x1b[22;39m"
+	ansiDim          = "\x1b[2m"
+	ansiBold         = "\x1b[1m"
+)
+
+// default terminal size when it can't be queried.
+const (
+	defaultTerminalWidth  = 80
+	defaultTerminalHeight = 24
+)
+
+// terminalAction is the outcome of handling a chunk of terminal input.
+type terminalAction int
+
+const (
+	terminalContinue terminalAction = iota
+	terminalAccept
+	terminalCancel
+)
+
+// TerminalMenu is an interactive menu drawn on a terminal. It filters items
+// with the same menu and search methods as the GUI.
+type TerminalMenu struct {
+	cfg        *model.Config
+	menu       *menu
+	menuCancel context.CancelFunc
+	itemFormat model.ItemFormat
+	// keyBindings maps key chords to actions.
+	keyBindings keyBindings
+	previewer   *Previewer
+	// store keeps the query history and usage, nil without a menu ID.
+	store store.Store
+	// exitCode is the accept code of the key that accepted the selection.
+	exitCode model.ExitCode
+	// redraw is signalled when the screen needs to be drawn again.
+	redraw chan struct{}
+
+	// mu guards the input line, scroll offset, header lines and preview text.
+	mu          sync.Mutex
+	input       []rune
+	cursor      int
+	offset      int
+	headerLines []string
+	previewText string
+}
+
+// NewTerminalMenu creates a terminal menu for the given input lines.
+func NewTerminalMenu(cfg *model.Config, searchMethod SearchMethod, items []string) (*TerminalMenu, error) {
+	itemFormat, err := model.NewItemFormat(cfg.InputFormat, cfg.Delimiter, cfg.WithNth, cfg.OutputNth, cfg.GroupNth)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := newKeyBindings(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGroupOrder(cfg.GroupOrder); err != nil {
+		return nil, err
+	}
+	// the store keeps the query history and the usage that ranks items
+	var menuStore store.Store
+	if cfg.MenuID != "" {
+		if menuStore, err = newMenuStore(cfg); err != nil {
+			logrus.WithError(err).Warn("failed to open the menu store, history and ranking are disabled")
+			menuStore = nil
+		}
+	}
+	query := initialInput(menuStore, cfg.InitialQuery)
+	ctx, cancel := context.WithCancel(context.Background())
+	m, err := newMenu(ctx, items, query, groupedSearchMethod(cfg, rankedSearch(menuStore, searchMethod, cfg.PreserveOrder)), cfg.PreserveOrder, cfg.IgnoreDiacritics, cfg.MaxResults, itemFormat)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	t := &TerminalMenu{
+		cfg:         cfg,
+		menu:        m,
+		menuCancel:  cancel,
+		itemFormat:  itemFormat,
+		keyBindings: bindings,
+		store:       menuStore,
+		exitCode:    model.NoError,
+		redraw:      make(chan struct{}, 1),
+		input:       []rune(query),
+	}
+	t.cursor = len(t.input)
+	m.narrowing = narrowingSearchMethods[cfg.SearchMethod]
+	if menuStore != nil {
+		m.history = loadQueryHistory(menuStore)
+	}
+	if cfg.Preview != "" {
+		t.previewer = NewPreviewer(cfg.Preview, PreviewDebounce, t.setPreviewText)
+	}
+	return t, nil
+}
+
+// Run draws the menu on tty and handles input until the user accepts or
+// cancels. The menu is drawn on the alternate screen so stdout stays clean.
+func (t *TerminalMenu) Run(tty *os.File) ([]model.MenuItem, error) {
+	defer t.Close()
+	fd := int(tty.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set raw terminal mode: %w", err)
+	}
+	defer func() {
+		_ = term.Restore(fd, oldState)
+	}()
+	_, _ = fmt.Fprint(tty, ansiAltScreenOn)
+	defer func() {
+		_, _ = fmt.Fprint(tty, ansiShowCursor+ansiAltScreenOff)
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	inputCh := make(chan []byte)
+	errCh := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := tty.Read(buf)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			inputCh <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	size := func() (int, int) {
+		width, height, err := term.GetSize(fd)
+		if err != nil || width <= 0 || height <= 0 {
+			return defaultTerminalWidth, defaultTerminalHeight
+		}
+		return width, height
+	}
+	draw := func() {
+		width, height := size()
+		var frame bytes.Buffer
+		t.render(&frame, width, height)
+		_, _ = tty.Write(frame.Bytes())
+	}
+
+	draw()
+	t.requestPreview()
+	for {
+		select {
+		case data := <-inputCh:
+			_, height := size()
+			switch t.handleInput(data, t.listHeight(height)) {
+			case terminalAccept:
+				selection := t.Selection()
+				t.recordSelection(selection)
+				return selection, nil
+			case terminalCancel:
+				return nil, ErrTerminalCancelled
+			}
+			draw()
+			t.requestPreview()
+		case <-t.redraw:
+			draw()
+			t.requestPreview()
+		case err := <-errCh:
+			return nil, fmt.Errorf("reading input: %w", err)
+		case <-sigCh:
+			return nil, ErrTerminalInterrupted
+		case <-t.menu.ctx.Done():
+			return nil, ErrTerminalCancelled
+		}
+	}
+}
+
+// Close stops the preview command and releases the menu. A running Run
+// returns ErrTerminalCancelled.
+func (t *TerminalMenu) Close() {
+	if t.previewer != nil {
+		t.previewer.Stop()
+	}
+	t.menuCancel()
+}
+
+// AppendItems adds input lines to the menu while it is running. Lines that
+// are already listed are skipped.
+func (t *TerminalMenu) AppendItems(items []string) {
+	t.menu.appendItems(t.menu.titlesToMenuItem(items))
+	t.menu.refresh()
+	t.requestRedraw()
+}
+
+// SetLoading marks the menu as still receiving items.
+func (t *TerminalMenu) SetLoading(loading bool) {
+	t.menu.setLoading(loading)
+	t.requestRedraw()
+}
+
+// SetHeaderLines shows lines above the list as fixed headers. They are
+// neither searched nor selectable.
+func (t *TerminalMenu) SetHeaderLines(lines []string) {
+	t.mu.Lock()
+	t.headerLines = lines
+	t.mu.Unlock()
+	t.requestRedraw()
+}
+
+// Query returns the current search query.
+func (t *TerminalMenu) Query() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.input)
+}
+
+// Selection returns the marked items in multi-select mode, else the selected
+// item, or the query itself when nothing matches and custom selections are
+// accepted.
+func (t *TerminalMenu) Selection() []model.MenuItem {
+	if t.cfg.Multi {
+		if marked := t.menu.markedItems(); len(marked) > 0 {
+			return marked
+		}
+	}
+	t.menu.itemsMutex.Lock()
+	defer t.menu.itemsMutex.Unlock()
+	if selected := t.menu.selectedLocked(); selected != nil {
+		return []model.MenuItem{*selected}
+	}
+	if t.cfg.AcceptCustomSelection {
+		return []model.MenuItem{{Title: t.Query()}}
+	}
+	return nil
+}
+
+// ExitCode returns the exit code of the accepted selection: NoError for the
+// accept key or the code of a custom accept key.
+func (t *TerminalMenu) ExitCode() model.ExitCode {
+	return t.exitCode
+}
+
+// ItemOutput returns the text printed for an accepted item.
+func (t *TerminalMenu) ItemOutput(item model.MenuItem) string {
+	return t.itemFormat.Output(item)
+}
+
+// handleInput applies a chunk of raw terminal input. pageSize is the number of
+// items moved by Page Up/Page Down.
+func (t *TerminalMenu) handleInput(data []byte, pageSize int) terminalAction {
+	for i := 0; i < len(data); {
+		chord, text, n := decodeTerminalKey(data[i:])
+		i += n
+		if chord.name == "" {
+			if text == 0 {
+				continue
+			}
+			if text >= '1' && text <= '9' && !t.cfg.NoNumericSelection && t.selectNumeric(int(text-'1')) {
+				return terminalAccept
+			}
+			t.updateInput(func() {
+				t.input = append(t.input[:t.cursor], append([]rune{text}, t.input[t.cursor:]...)...)
+				t.cursor++
+			})
+			continue
+		}
+		action, ok := t.keyBindings.lookup(chord)
+		if !ok && chord == ctrlJ {
+			// ctrl+j is a newline, it accepts unless it is bound to something else
+			action, ok = t.keyBindings.lookup(keyChord{name: fyne.KeyReturn})
+		}
+		if ok {
+			if result := t.runKeyAction(action, pageSize); result != terminalContinue {
+				return result
+			}
+			continue
+		}
+		if chord == (keyChord{name: fyne.KeyC, modifier: fyne.KeyModifierControl}) {
+			return terminalCancel
+		}
+		t.editLine(chord)
+	}
+	return terminalContinue
+}
+
+// runKeyAction performs a bound action in the terminal.
+func (t *TerminalMenu) runKeyAction(action model.KeyAction, pageSize int) terminalAction {
+	switch action {
+	case model.ActionUp:
+		t.moveSelectionWrapped(-1)
+	case model.ActionDown:
+		t.moveSelectionWrapped(1)
+	case model.ActionPageUp:
+		t.menu.moveSelection(-pageSize)
+	case model.ActionPageDown:
+		t.menu.moveSelection(pageSize)
+	case model.ActionFirst:
+		t.menu.selectIndex(0)
+	case model.ActionLast:
+		t.menu.selectIndex(math.MaxInt)
+	case model.ActionAccept:
+		if t.canAccept() {
+			return terminalAccept
+		}
+	case model.ActionCancel:
+		return terminalCancel
+	case model.ActionClearQuery:
+		t.setInput("")
+	case model.ActionToggleMark:
+		if t.cfg.Multi {
+			t.toggleMark()
+		}
+	case model.ActionHistoryPrev:
+		if query, ok := t.menu.history.prev(t.Query()); ok {
+			t.setInput(query)
+		}
+	case model.ActionHistoryNext:
+		if query, ok := t.menu.history.next(); ok {
+			t.setInput(query)
+		}
+	case model.ActionHistorySearch:
+		if query, ok := t.menu.history.search(t.Query()); ok {
+			t.setInput(query)
+		}
+	default:
+		if n, ok := action.CustomAccept(); ok && t.canAccept() {
+			t.exitCode = model.CustomAcceptCode(n)
+			return terminalAccept
+		}
+	}
+	return terminalContinue
+}
+
+// editLine applies the line editing keys that aren't bound to an action.
+func (t *TerminalMenu) editLine(chord keyChord) {
+	switch chord {
+	case keyChord{name: fyne.KeyA, modifier: fyne.KeyModifierControl}:
+		t.editInput(func() { t.cursor = 0 })
+	case keyChord{name: fyne.KeyE, modifier: fyne.KeyModifierControl}:
+		t.editInput(func() { t.cursor = len(t.input) })
+	case keyChord{name: fyne.KeyLeft}:
+		t.editInput(func() { t.cursor = max(t.cursor-1, 0) })
+	case keyChord{name: fyne.KeyRight}:
+		t.editInput(func() { t.cursor = min(t.cursor+1, len(t.input)) })
+	case keyChord{name: fyne.KeyBackspace}:
+		t.updateInput(func() {
+			if t.cursor > 0 {
+				t.input = append(t.input[:t.cursor-1], t.input[t.cursor:]...)
+				t.cursor--
+			}
+		})
+	case keyChord{name: fyne.KeyDelete}:
+		t.updateInput(func() {
+			if t.cursor < len(t.input) {
+				t.input = append(t.input[:t.cursor], t.input[t.cursor+1:]...)
+			}
+		})
+	case keyChord{name: fyne.KeyU, modifier: fyne.KeyModifierControl}:
+		t.updateInput(func() {
+			t.input = t.input[t.cursor:]
+			t.cursor = 0
+		})
+	case keyChord{name: fyne.KeyW, modifier: fyne.KeyModifierControl}:
+		t.updateInput(func() {
+			start := t.cursor
+			for start > 0 && unicode.IsSpace(t.input[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(t.input[start-1]) {
+				start--
+			}
+			t.input = append(t.input[:start], t.input[t.cursor:]...)
+			t.cursor = start
+		})
+	}
+}
+
+// setInput replaces the query with the cursor at its end.
+func (t *TerminalMenu) setInput(query string) {
+	t.updateInput(func() {
+		t.input = []rune(query)
+		t.cursor = len(t.input)
+	})
+}
+
+// recordSelection saves the query and the accepted items like the GUI does,
+// so they rank higher next time.
+func (t *TerminalMenu) recordSelection(selection []model.MenuItem) {
+	if t.store == nil {
+		return
+	}
+	values := make([]string, 0, len(selection))
+	for _, item := range selection {
+		values = append(values, item.Key())
+	}
+	if err := recordSelection(t.store, t.Query(), values); err != nil {
+		logrus.WithError(err).Warn("failed to save the selection")
+	}
+}
+
+// editInput changes the cursor without changing the query.
+func (t *TerminalMenu) editInput(edit func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	edit()
+}
+
+// updateInput changes the query and searches again.
+func (t *TerminalMenu) updateInput(edit func()) {
+	t.mu.Lock()
+	before := string(t.input)
+	edit()
+	query := string(t.input)
+	if query != before {
+		t.offset = 0
+	}
+	t.mu.Unlock()
+	if query != before {
+		t.menu.Search(query)
+	}
+}
+
+// moveSelectionWrapped moves the selection by one, wrapping around the ends
+// like the GUI does.
+func (t *TerminalMenu) moveSelectionWrapped(delta int) {
+	t.menu.itemsMutex.Lock()
+	defer t.menu.itemsMutex.Unlock()
+	if len(t.menu.Filtered) == 0 {
+		return
+	}
+	t.menu.Selected = (t.menu.Selected + delta + len(t.menu.Filtered)) % len(t.menu.Filtered)
+}
+
+// toggleMark marks or unmarks the selected item and moves to the next one.
+func (t *TerminalMenu) toggleMark() {
+	if !t.menu.toggleMarkSelected() {
+		return
+	}
+	t.menu.itemsMutex.Lock()
+	defer t.menu.itemsMutex.Unlock()
+	if t.menu.Selected < len(t.menu.Filtered)-1 {
+		t.menu.Selected++
+	}
+}
+
+// selectNumeric selects the item shown with the given zero-based number hint.
+func (t *TerminalMenu) selectNumeric(idx int) bool {
+	t.menu.itemsMutex.Lock()
+	defer t.menu.itemsMutex.Unlock()
+	if idx >= len(t.menu.Filtered) {
+		return false
+	}
+	t.menu.Selected = idx
+	return true
+}
+
+func (t *TerminalMenu) canAccept() bool {
+	t.menu.itemsMutex.Lock()
+	defer t.menu.itemsMutex.Unlock()
+	return (!t.menu.placeholder && len(t.menu.Filtered) > 0) || t.cfg.AcceptCustomSelection
+}
+
+// listHeight returns how many item rows fit on a screen of the given height.
+func (t *TerminalMenu) listHeight(height int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// the prompt line, message and header lines come first
+	rows := height - 1 - t.fixedRowsLocked()
+	if t.previewer != nil {
+		rows -= rows / 2
+	}
+	return max(rows, 1)
+}
+
+// requestPreview asks the previewer for the selected item.
+func (t *TerminalMenu) requestPreview() {
+	if t.previewer == nil {
+		return
+	}
+	entry := ""
+	t.menu.itemsMutex.Lock()
+	if selected := t.menu.selectedLocked(); selected != nil {
+		entry = t.itemFormat.Output(*selected)
+	}
+	t.menu.itemsMutex.Unlock()
+	t.previewer.Request(entry)
+}
+
+func (t *TerminalMenu) setPreviewText(text string) {
+	t.mu.Lock()
+	t.previewText = text
+	t.mu.Unlock()
+	t.requestRedraw()
+}
+
+// fixedRowsLocked returns the number of rows drawn between the prompt and
+// the list: the message and the header lines. t.mu must be held.
+func (t *TerminalMenu) fixedRowsLocked() int {
+	rows := len(t.headerLines)
+	if t.cfg.Message != "" {
+		rows++
+	}
+	return rows
+}
+
+func (t *TerminalMenu) requestRedraw() {
+	select {
+	case t.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// render draws a full frame: the prompt line, the message and header lines,
+// the visible items with the selected one highlighted, and the preview below
+// them.
+func (t *TerminalMenu) render(w *bytes.Buffer, width, height int) {
+	counter := t.menu.counterLabel()
+	t.menu.itemsMutex.Lock()
+	filtered := t.menu.Filtered
+	selected := t.menu.Selected
+	var marked map[string]struct{}
+	if t.cfg.Multi {
+		marked = maps.Clone(t.menu.marked)
+	}
+	t.menu.itemsMutex.Unlock()
+
+	rows := t.listHeight(height)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// t.offset counts list rows, which include the headers of grouped items
+	listRows := model.GroupRows(filtered)
+	if selected >= 0 {
+		row := model.ItemRow(listRows, selected)
+		top := row
+		// keep the header of the group in view with its first item
+		if listRows != nil && row > 0 && listRows[row-1].IsHeader() {
+			top = row - 1
+		}
+		if top < t.offset {
+			t.offset = top
+		} else if row >= t.offset+rows {
+			t.offset = row - rows + 1
+		}
+	}
+
+	w.WriteString(ansiHideCursor + ansiHome)
+
+	// prompt line with the match counter on the right
+	prompt := t.cfg.Prompt
+	if prompt != "" && !strings.HasSuffix(prompt, " ") {
+		prompt += " "
+	}
+	counterWidth := len([]rune(counter))
+	line := truncateRunes(prompt+string(t.input), width-counterWidth-1)
+	w.WriteString(ansiClearLine + line)
+	if pad := width - len([]rune(line)) - counterWidth; pad > 0 {
+		w.WriteString(strings.Repeat(" ", pad) + ansiDim + counter + ansiReset)
+	}
+	if t.cfg.Message != "" {
+		w.WriteString("\r\n" + ansiClearLine + ansiDim + truncateRunes(sanitizeTerminalText(t.cfg.Message), width) + ansiReset)
+	}
+	// header lines are indented like items so their columns line up
+	for _, header := range t.headerLines {
+		w.WriteString("\r\n" + ansiClearLine + ansiBold + truncateRunes("  "+sanitizeTerminalText(header), width) + ansiReset)
+	}
+
+	for row := 0; row < rows; row++ {
+		w.WriteString("\r\n" + ansiClearLine)
+		idx := t.offset + row
+		if listRows != nil {
+			if idx >= len(listRows) {
+				continue
+			}
+			if listRows[idx].IsHeader() {
+				w.WriteString(ansiDim + truncateRunes(sanitizeTerminalText(listRows[idx].Header), width) + ansiReset)
+				continue
+			}
+			idx = listRows[idx].Item
+		}
+		if idx >= len(filtered) {
+			continue
+		}
+		_, isMarked := marked[filtered[idx].Key()]
+		t.renderItem(w, filtered[idx], idx, idx == selected, isMarked, width)
+	}
+
+	if t.previewer != nil {
+		previewRows := height - 1 - t.fixedRowsLocked() - rows
+		w.WriteString("\r\n" + ansiClearLine + ansiDim + strings.Repeat("─", width) + ansiReset)
+		lines := strings.Split(strings.TrimRight(t.previewText, "\n"), "\n")
+		for row := 0; row < previewRows-1 && row < len(lines); row++ {
+			previewLine := strings.ReplaceAll(lines[row], "\t", "    ")
+			w.WriteString("\r\n" + ansiClearLine + truncateRunes(sanitizeTerminalText(previewLine), width))
+		}
+	}
+
+	w.WriteString(ansiClearBelow)
+	// park the cursor in the input line
+	cursorCol := len([]rune(prompt)) + t.cursor + 1
+	fmt.Fprintf(w, "\x1b[1;%dH%s", min(cursorCol, width), ansiShowCursor)
+}
+
+// renderItem draws a single item row with its matched characters highlighted.
+// Marked items get a * next to the selection marker.
+func (t *TerminalMenu) renderItem(w *bytes.Buffer, item model.MenuItem, idx int, selected, marked bool, width int) {
+	cursor, mark := " ", " "
+	if selected {
+		cursor = ">"
+	}
+	if marked {
+		mark = "*"
+	}
+	prefix := cursor + mark
+	if !t.cfg.NoNumericSelection && idx < 9 {
+		prefix += fmt.Sprintf("%d. ", idx+1)
+	}
+	title := []rune(sanitizeTerminalText(item.ComputedTitle()))
+	title = title[:min(len(title), max(width-len(prefix), 0))]
+
+	if selected {
+		w.WriteString(ansiReverse)
+	}
+	w.WriteString(prefix)
+	pos := 0
+	for _, r := range item.Matches {
+		start, end := max(r.Start, pos), min(r.End, len(title))
+		if start >= end {
+			continue
+		}
+		w.WriteString(string(title[pos:start]) + ansiMatchOn + string(title[start:end]) + ansiMatchOff)
+		pos = end
+	}
+	w.WriteString(string(title[pos:]))
+	if selected {
+		// fill the row so the highlight spans the full width
+		if pad := width - len(prefix) - len(title); pad > 0 {
+			w.WriteString(strings.Repeat(" ", pad))
+		}
+		w.WriteString(ansiReset)
+	}
+}
+
+// decodeTerminalKey decodes the key at the start of raw terminal input. It
+// returns the chord of a special or control key, or the typed character with
+// an empty chord, along with the number of bytes consumed. Typed characters
+// are decoded as UTF-8 so input in any script is accepted.
+func decodeTerminalKey(data []byte) (keyChord, rune, int) {
+	b := data[0]
+	switch {
+	case b == 0x1b:
+		return parseEscape(data)
+	case b == '\r':
+		return keyChord{name: fyne.KeyReturn}, 0, 1
+	case b == '\t':
+		return keyChord{name: fyne.KeyTab}, 0, 1
+	case b == 127 || b == 8:
+		return keyChord{name: fyne.KeyBackspace}, 0, 1
+	case b == 0:
+		return keyChord{name: fyne.KeySpace, modifier: fyne.KeyModifierControl}, 0, 1
+	case b >= 1 && b <= 26:
+		return keyChord{name: fyne.KeyName(rune('A' + b - 1)), modifier: fyne.KeyModifierControl}, 0, 1
+	case b >= 32 && b <= 126:
+		return keyChord{}, rune(b), 1
+	}
+	r, size := utf8.DecodeRune(data)
+	if r == utf8.RuneError || unicode.IsControl(r) {
+		return keyChord{}, 0, size
+	}
+	return keyChord{}, r, size
+}
+
+// ctrlJ is the chord terminals send for a newline.
+var ctrlJ = keyChord{name: fyne.KeyJ, modifier: fyne.KeyModifierControl}
+
+// escapeKeys maps the final byte of CSI and SS3 sequences to keys.
+var escapeKeys = map[byte]fyne.KeyName{
+	'A': fyne.KeyUp,
+	'B': fyne.KeyDown,
+	'C': fyne.KeyRight,
+	'D': fyne.KeyLeft,
+	'H': fyne.KeyHome,
+	'F': fyne.KeyEnd,
+}
+
+// tildeKeys maps the parameter of "CSI n ~" sequences to keys.
+var tildeKeys = map[string]fyne.KeyName{
+	"1": fyne.KeyHome,
+	"7": fyne.KeyHome,
+	"4": fyne.KeyEnd,
+	"8": fyne.KeyEnd,
+	"3": fyne.KeyDelete,
+	"5": fyne.KeyPageUp,
+	"6": fyne.KeyPageDown,
+}
+
+// parseEscape decodes an escape sequence at the start of data into a chord.
+// A lone escape is the escape key and escape followed by a character is that
+// character with alt. Unknown sequences, and alt with characters that can't
+// be bound such as non-ASCII letters, are skipped whole with an empty chord.
+func parseEscape(data []byte) (keyChord, rune, int) {
+	if len(data) < 2 {
+		return keyChord{name: fyne.KeyEscape}, 0, 1
+	}
+	if data[1] != '[' && data[1] != 'O' {
+		chord, text, n := decodeTerminalKey(data[1:])
+		if text != 0 {
+			chord, _ = parseKeyChord(string(text))
+		}
+		if chord.name == "" {
+			return keyChord{}, 0, n + 1
+		}
+		chord.modifier |= fyne.KeyModifierAlt
+		return chord, 0, n + 1
+	}
+	end := 2
+	for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+		end++
+	}
+	if end >= len(data) {
+		return keyChord{}, 0, len(data)
+	}
+	params := string(data[2:end])
+	var chord keyChord
+	if data[end] == '~' {
+		key, _, _ := strings.Cut(params, ";")
+		chord.name = tildeKeys[key]
+	} else if data[end] == 'Z' {
+		chord = keyChord{name: fyne.KeyTab, modifier: fyne.KeyModifierShift}
+	} else {
+		chord.name = escapeKeys[data[end]]
+	}
+	// xterm encodes modifiers as a second parameter, e.g. "1;5C" for ctrl+right
+	if _, mod, ok := strings.Cut(params, ";"); ok && chord.name != "" {
+		chord.modifier |= xtermModifier(mod)
+	}
+	return chord, 0, end + 1
+}
+
+// xtermModifier decodes the modifier parameter of an xterm key sequence.
+func xtermModifier(param string) fyne.KeyModifier {
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 2 {
+		return 0
+	}
+	var modifier fyne.KeyModifier
+	if (n-1)&1 != 0 {
+		modifier |= fyne.KeyModifierShift
+	}
+	if (n-1)&2 != 0 {
+		modifier |= fyne.KeyModifierAlt
+	}
+	if (n-1)&4 != 0 {
+		modifier |= fyne.KeyModifierControl
+	}
+	return modifier
+}
+
+// sanitizeTerminalText replaces control characters so they can't move the
+// cursor or break the layout.
+func sanitizeTerminalText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// truncateRunes shortens s to at most n runes.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if n < 0 {
+		n = 0
+	}
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
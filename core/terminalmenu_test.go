@@ -0,0 +1,333 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTerminalMenu(t *testing.T, cfg *model.Config, items ...string) *TerminalMenu {
+	t.Helper()
+	if cfg.SearchMethod == "" {
+		cfg.SearchMethod = "fuzzy"
+	}
+	menu, err := NewTerminalMenu(cfg, SearchMethods[cfg.SearchMethod], items)
+	require.NoError(t, err)
+	t.Cleanup(menu.Close)
+	return menu
+}
+
+func TestDecodeTerminalKey(t *testing.T) {
+	ctrl := fyne.KeyModifierControl
+	tests := []struct {
+		input    string
+		expected keyChord
+		text     rune
+		length   int
+	}{
+		{input: "\x1b", expected: keyChord{name: fyne.KeyEscape}, length: 1},
+		{input: "\x1b[A", expected: keyChord{name: fyne.KeyUp}, length: 3},
+		{input: "\x1b[Bx", expected: keyChord{name: fyne.KeyDown}, length: 3},
+		{input: "\x1bOH", expected: keyChord{name: fyne.KeyHome}, length: 3},
+		{input: "\x1b[4~", expected: keyChord{name: fyne.KeyEnd}, length: 4},
+		{input: "\x1b[5~", expected: keyChord{name: fyne.KeyPageUp}, length: 4},
+		{input: "\x1b[6~", expected: keyChord{name: fyne.KeyPageDown}, length: 4},
+		{input: "\x1b[3~", expected: keyChord{name: fyne.KeyDelete}, length: 4},
+		{input: "\x1b[1;5C", expected: keyChord{name: fyne.KeyRight, modifier: ctrl}, length: 6},
+		{input: "\x1b[Z", expected: keyChord{name: fyne.KeyTab, modifier: fyne.KeyModifierShift}, length: 3},
+		{input: "\x1bp", expected: keyChord{name: fyne.KeyP, modifier: fyne.KeyModifierAlt}, length: 2},
+		{input: "\x1b[99X", expected: keyChord{}, length: 5},
+		{input: "\x0e", expected: keyChord{name: fyne.KeyN, modifier: ctrl}, length: 1},
+		{input: "\r", expected: keyChord{name: fyne.KeyReturn}, length: 1},
+		{input: "\n", expected: keyChord{name: fyne.KeyJ, modifier: ctrl}, length: 1},
+		{input: "\x7f", expected: keyChord{name: fyne.KeyBackspace}, length: 1},
+		{input: "a", text: 'a', length: 1},
+		{input: "öx", text: 'ö', length: 2},
+		{input: "ف", text: 'ف', length: 2},
+		{input: "字", text: '字', length: 3},
+		{input: "\x1bö", expected: keyChord{}, length: 3},
+		{input: "\xff", expected: keyChord{}, length: 1},
+	}
+	for _, tt := range tests {
+		chord, text, n := decodeTerminalKey([]byte(tt.input))
+		assert.Equal(t, tt.expected, chord, "%q", tt.input)
+		assert.Equal(t, tt.text, text, "%q", tt.input)
+		assert.Equal(t, tt.length, n, "%q", tt.input)
+	}
+}
+
+func TestTerminalMenuUnicodeInput(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "schön", "فارسی", "plain")
+	assert.Equal(t, terminalContinue, menu.handleInput([]byte("ö"), 10))
+	assert.Equal(t, "ö", menu.Query())
+	assert.Equal(t, []string{"schön"}, itemsToStr(menu.Selection()))
+
+	menu.handleInput([]byte("\x7f"), 10)
+	assert.Equal(t, "", menu.Query(), "backspace deletes the whole character")
+
+	// alt with a non-ASCII letter is skipped without leaving stray bytes
+	menu.handleInput([]byte("\x1böفا"), 10)
+	assert.Equal(t, "فا", menu.Query())
+	assert.Equal(t, []string{"فارسی"}, itemsToStr(menu.Selection()))
+}
+
+func TestTerminalMenuUsesSearchMethod(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "apple pie", "banana", "apricot")
+
+	// "apc" only matches apricot with the fuzzy search method
+	assert.Equal(t, terminalContinue, menu.handleInput([]byte("apc"), 10))
+	assert.Equal(t, []string{"apricot"}, itemsToStr(menu.menu.Filtered))
+	assert.Equal(t, "apc", menu.Query())
+
+	// backspace and ctrl+u edit the query
+	menu.handleInput([]byte{127}, 10)
+	assert.Equal(t, "ap", menu.Query())
+	menu.handleInput([]byte{21}, 10)
+	assert.Equal(t, "", menu.Query())
+	assert.Len(t, menu.menu.Filtered, 3)
+}
+
+func TestTerminalMenuNavigation(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "one", "two", "three", "four")
+
+	menu.handleInput([]byte("\x1b[B"), 10)
+	assert.Equal(t, 1, menu.menu.Selected)
+	menu.handleInput([]byte{14}, 10) // ctrl+n
+	assert.Equal(t, 2, menu.menu.Selected)
+	menu.handleInput([]byte{16}, 10) // ctrl+p
+	assert.Equal(t, 1, menu.menu.Selected)
+	menu.handleInput([]byte("\x1b[A\x1b[A"), 10) // wraps around
+	assert.Equal(t, 3, menu.menu.Selected)
+	menu.handleInput([]byte("\x1b[5~"), 2)
+	assert.Equal(t, 1, menu.menu.Selected)
+	menu.handleInput([]byte("\x1b[F"), 2)
+	assert.Equal(t, 3, menu.menu.Selected)
+
+	assert.Equal(t, terminalAccept, menu.handleInput([]byte("\r"), 10))
+	assert.Equal(t, []string{"four"}, itemsToStr(menu.Selection()))
+}
+
+func TestTerminalMenuInlineCursor(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "abc")
+
+	menu.handleInput([]byte("ac"), 10)
+	menu.handleInput([]byte("\x1b[D"), 10) // left
+	menu.handleInput([]byte("b"), 10)
+	assert.Equal(t, "abc", menu.Query())
+	menu.handleInput([]byte{1}, 10) // ctrl+a
+	menu.handleInput([]byte("\x1b[3~"), 10)
+	assert.Equal(t, "bc", menu.Query())
+}
+
+func TestTerminalMenuNumericSelection(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{}, "one", "two", "three")
+
+	// digits beyond the list are typed into the query
+	assert.Equal(t, terminalContinue, menu.handleInput([]byte("9"), 10))
+	assert.Equal(t, "9", menu.Query())
+	menu.handleInput([]byte{127}, 10)
+
+	assert.Equal(t, terminalAccept, menu.handleInput([]byte("2"), 10))
+	assert.Equal(t, []string{"two"}, itemsToStr(menu.Selection()))
+}
+
+func TestTerminalMenuCancelAndCustomSelection(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "one")
+	assert.Equal(t, terminalCancel, menu.handleInput([]byte("\x1b"), 10))
+	assert.Equal(t, terminalCancel, menu.handleInput([]byte{3}, 10))
+
+	strict := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "one")
+	strict.handleInput([]byte("zzz"), 10)
+	assert.Equal(t, terminalContinue, strict.handleInput([]byte("\r"), 10))
+
+	custom := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true, AcceptCustomSelection: true}, "one")
+	custom.handleInput([]byte("zzz"), 10)
+	assert.Equal(t, terminalAccept, custom.handleInput([]byte("\r"), 10))
+	assert.Equal(t, []string{"zzz"}, itemsToStr(custom.Selection()))
+}
+
+func TestTerminalMenuRender(t *testing.T) {
+	items := make([]string, 50)
+	for i := range items {
+		items[i] = "item " + strings.Repeat("x", i%3) + string(rune('a'+i%26))
+	}
+	menu := newTestTerminalMenu(t, &model.Config{Prompt: "pick:", NoNumericSelection: true, SearchMethod: "direct"}, items...)
+
+	var frame bytes.Buffer
+	menu.render(&frame, 40, 6)
+	out := frame.String()
+	assert.Contains(t, out, "pick: ")
+	assert.Contains(t, out, "[50/50]")
+	assert.Contains(t, out, ansiReverse+"> item a")
+	assert.NotContains(t, out, "item f", "only the rows that fit are drawn")
+
+	// the list scrolls to keep the selection visible
+	menu.handleInput([]byte("\x1b[F"), 5)
+	frame.Reset()
+	menu.render(&frame, 40, 6)
+	assert.Contains(t, frame.String(), "> item xx")
+
+	// matched characters are highlighted
+	menu.handleInput([]byte("xxc"), 5)
+	frame.Reset()
+	menu.render(&frame, 40, 6)
+	assert.Contains(t, frame.String(), "item "+ansiMatchOn+"xxc"+ansiMatchOff)
+}
+
+func TestTerminalMenuStreamingItems(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true})
+	menu.SetLoading(true)
+	assert.Nil(t, menu.Selection(), "the loading item can't be accepted")
+	assert.Equal(t, terminalContinue, menu.handleInput([]byte("\r"), 10))
+
+	menu.AppendItems([]string{"one", "two"})
+	var frame bytes.Buffer
+	menu.render(&frame, 40, 6)
+	assert.Contains(t, frame.String(), "[2/2…]")
+
+	menu.handleInput([]byte("\x1b[B"), 10)
+	menu.AppendItems([]string{"three"})
+	menu.SetLoading(false)
+	frame.Reset()
+	menu.render(&frame, 40, 6)
+	assert.Contains(t, frame.String(), "[3/3]")
+	assert.Equal(t, []string{"two"}, itemsToStr(menu.Selection()))
+}
+
+func TestTerminalMenuHeaderLines(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{Message: "pick a process", NoNumericSelection: true}, "1 init", "2 sh", "3 vim")
+	menu.SetHeaderLines([]string{"PID CMD"})
+	assert.Equal(t, 2, menu.listHeight(5), "the message and header take two rows")
+
+	var frame bytes.Buffer
+	menu.render(&frame, 40, 5)
+	out := frame.String()
+	message := strings.Index(out, ansiDim+"pick a process"+ansiReset)
+	header := strings.Index(out, ansiBold+"  PID CMD"+ansiReset)
+	first := strings.Index(out, "> 1 init")
+	require.True(t, message >= 0 && header >= 0 && first >= 0, out)
+	assert.Less(t, message, header)
+	assert.Less(t, header, first)
+	assert.NotContains(t, out, "3 vim", "the fixed rows take space from the list")
+
+	// headers are never searched
+	menu.handleInput([]byte("PID"), 2)
+	frame.Reset()
+	menu.render(&frame, 40, 5)
+	assert.Contains(t, frame.String(), "[0/3]")
+	assert.Contains(t, frame.String(), "PID CMD")
+}
+
+func TestTerminalMenuKeybindings(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{
+		NoNumericSelection: true,
+		Keybindings:        map[string]string{"ctrl+j": "down", "ctrl+k": "up", "ctrl+n": "none", "alt+a": "accept"},
+	}, "one", "two", "three")
+
+	menu.handleInput([]byte{10}, 10) // ctrl+j
+	assert.Equal(t, 1, menu.menu.Selected)
+	menu.handleInput([]byte{11, 11}, 10) // ctrl+k
+	assert.Equal(t, 2, menu.menu.Selected)
+	menu.handleInput([]byte{14}, 10) // ctrl+n is unbound
+	assert.Equal(t, 2, menu.menu.Selected)
+
+	menu.handleInput([]byte("tw"), 10)
+	menu.handleInput([]byte{12}, 10) // ctrl+l clears the query
+	assert.Equal(t, "", menu.Query())
+
+	assert.Equal(t, terminalAccept, menu.handleInput([]byte("\x1ba"), 10))
+}
+
+func TestTerminalMenuCustomAccept(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{
+		NoNumericSelection: true,
+		Keybindings:        map[string]string{"alt+1": "custom-1"},
+	}, "one", "two")
+	assert.Equal(t, model.NoError, menu.ExitCode())
+
+	menu.handleInput([]byte("\x1b[B"), 10)
+	assert.Equal(t, terminalAccept, menu.handleInput([]byte("\x1b1"), 10))
+	assert.Equal(t, model.CustomAcceptCode(1), menu.ExitCode())
+	assert.Equal(t, []string{"two"}, itemsToStr(menu.Selection()))
+}
+
+func TestTerminalMenuQueryHistory(t *testing.T) {
+	cfg := &model.Config{MenuID: "terminal-history-test", StateDir: t.TempDir(), NoNumericSelection: true}
+	st, err := newMenuStore(cfg)
+	require.NoError(t, err)
+	require.NoError(t, st.UpdateCache(func(cache *store.Cache) error {
+		cache.AddQuery("one")
+		cache.AddQuery("two")
+		return nil
+	}))
+	menu := newTestTerminalMenu(t, cfg, "one", "two", "three")
+
+	menu.handleInput([]byte("t\x1bp"), 10)
+	assert.Equal(t, "two", menu.Query())
+	menu.handleInput([]byte("\x1bp"), 10)
+	assert.Equal(t, "one", menu.Query())
+	menu.handleInput([]byte("\x1bn\x1bn"), 10)
+	assert.Equal(t, "t", menu.Query())
+
+	menu.handleInput([]byte("\x0c"), 10) // ctrl+l clears the query
+	menu.handleInput([]byte("o\x12"), 10)
+	assert.Equal(t, "two", menu.Query())
+	var frame bytes.Buffer
+	menu.render(&frame, 80, 10)
+	assert.Contains(t, frame.String(), `history "o"`)
+	menu.handleInput([]byte("\x12"), 10)
+	assert.Equal(t, "one", menu.Query())
+
+	menu.handleInput([]byte("\x0cthr"), 10)
+	menu.recordSelection(menu.Selection())
+	cache, err := st.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "thr"}, cache.QueryHistory)
+	assert.Equal(t, "three", cache.LastEntry, "the accepted item is cached like in the GUI")
+	assert.Equal(t, 1, cache.UsageCount["three"])
+}
+
+func TestTerminalMenuFrecencyRanking(t *testing.T) {
+	cfg := &model.Config{MenuID: "terminal-frecency-test", StateDir: t.TempDir(), NoNumericSelection: true}
+	st, err := newMenuStore(cfg)
+	require.NoError(t, err)
+	require.NoError(t, st.UpdateCache(func(cache *store.Cache) error {
+		cache.RecordUsage("gamma")
+		return nil
+	}))
+	menu := newTestTerminalMenu(t, cfg, "alpha", "beta", "gamma")
+	assert.Equal(t, []string{"gamma", "alpha", "beta"}, itemsToStr(menu.menu.Filtered))
+}
+
+func TestTerminalMenuMultiSelect(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{Multi: true, NoNumericSelection: true}, "one", "two", "three")
+
+	// shift+tab marks the selected item and moves down
+	menu.handleInput([]byte("\x1b[Z"), 10)
+	menu.handleInput([]byte("\x1b[B\x1b[Z"), 10)
+	var frame bytes.Buffer
+	menu.render(&frame, 80, 10)
+	assert.Contains(t, frame.String(), " *one")
+	assert.Contains(t, frame.String(), ">*three", "the last item stays selected")
+	assert.Equal(t, []string{"one", "three"}, itemsToStr(menu.Selection()))
+
+	single := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "one", "two")
+	single.handleInput([]byte("\x1b[Z"), 10)
+	assert.Equal(t, []string{"one"}, itemsToStr(single.Selection()), "marks need --multi")
+}
+
+func TestTerminalMenuNewlineAccepts(t *testing.T) {
+	menu := newTestTerminalMenu(t, &model.Config{NoNumericSelection: true}, "one")
+	assert.Equal(t, terminalAccept, menu.handleInput([]byte("\n"), 10))
+}
+
+func TestTerminalMenuInvalidKeybinding(t *testing.T) {
+	_, err := NewTerminalMenu(&model.Config{Keybindings: map[string]string{"ctrl+j": "jump"}}, DirectSearch, nil)
+	assert.Error(t, err)
+}
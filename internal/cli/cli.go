@@ -2,10 +2,13 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hamidzr/gmenu/core"
 	"github.com/hamidzr/gmenu/internal/config"
@@ -14,10 +17,27 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	// streamBatchSize is the number of lines appended to the menu at once.
+	streamBatchSize = 1000
+	// streamFlushInterval is how often a partial batch is appended so lines
+	// from slow producers show up while they are still running.
+	streamFlushInterval = 50 * time.Millisecond
+)
+
+// errNoItems is returned when standard input has no lines.
+var errNoItems = errors.New("no items provided through standard input")
+
+// hasPipedInput reports whether standard input is a pipe or a file rather
+// than a terminal.
+func hasPipedInput() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && (info.Mode()&os.ModeCharDevice) == 0
+}
+
 func readItems() ([]string, error) {
 	var items []string
-	info, _ := os.Stdin.Stat()
-	if (info.Mode() & os.ModeCharDevice) == 0 {
+	if hasPipedInput() {
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -31,12 +51,101 @@ func readItems() ([]string, error) {
 	return items, nil
 }
 
+// splitHeaderLines splits the first n input lines off as headers.
+func splitHeaderLines(lines []string, n int) (headers, items []string) {
+	n = min(max(n, 0), len(lines))
+	return lines[:n], lines[n:]
+}
+
+// itemSink receives input lines while they are being read.
+type itemSink interface {
+	AppendItems(items []string)
+	SetLoading(loading bool)
+	SetHeaderLines(lines []string)
+}
+
+// streamItems reads lines from r and appends them to sink in batches until r
+// is exhausted. The first headerLines lines are passed to the sink as headers
+// rather than items. The sink is marked as loading meanwhile. It returns the
+// number of items read.
+func streamItems(r io.Reader, sink itemSink, headerLines int) (int, error) {
+	sink.SetLoading(true)
+	defer sink.SetLoading(false)
+
+	lines := make(chan string, streamBatchSize)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		errCh <- scanner.Err()
+	}()
+
+	ticker := time.NewTicker(streamFlushInterval)
+	defer ticker.Stop()
+	var batch, headers []string
+	count := 0
+	flush := func() {
+		if len(batch) > 0 {
+			sink.AppendItems(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if len(headers) < headerLines && len(headers) > 0 {
+					sink.SetHeaderLines(headers)
+				}
+				flush()
+				if err := <-errCh; err != nil {
+					return count, fmt.Errorf("error reading standard input: %w", err)
+				}
+				return count, nil
+			}
+			if len(headers) < headerLines {
+				headers = append(headers, line)
+				if len(headers) == headerLines {
+					sink.SetHeaderLines(headers)
+				}
+				continue
+			}
+			batch = append(batch, line)
+			count++
+			if len(batch) >= streamBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// streamInput streams standard input into sink. When reading fails or yields
+// no items the error is sent on errCh and stop is called.
+func streamInput(sink itemSink, headerLines int, errCh chan<- error, stop func()) {
+	count, err := streamItems(os.Stdin, sink, headerLines)
+	if err == nil && count == 0 {
+		err = errNoItems
+	}
+	if err != nil {
+		logrus.Error(err)
+		errCh <- err
+		stop()
+	}
+}
+
 func InitCLI() *cobra.Command {
 	RootCmd := &cobra.Command{
 		Use:           "gmenu",
 		Short:         "gmenu is a fuzzy menu selector",
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		// report unknown arguments ourselves rather than as unknown subcommands
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 0 {
 				msg := fmt.Sprintf("unknown argument(s): %s", strings.Join(args, " "))
@@ -75,47 +184,81 @@ func InitCLI() *cobra.Command {
 
 	// bind all flags using the new config system
 	config.BindFlags(RootCmd)
+	RootCmd.AddCommand(newDaemonCmd(), newClientCmd(), newLockCmd())
 
 	return RootCmd
 }
 
 func run(cfg *model.Config) error {
+	if cfg.OutputFormat != model.OutputFormatText && cfg.OutputFormat != model.OutputFormatJSON {
+		return model.NewExitError(model.UnknownError, fmt.Errorf("invalid output format: %s", cfg.OutputFormat))
+	}
 	searchMethod, ok := core.SearchMethods[cfg.SearchMethod]
 	if !ok {
 		return model.NewExitError(model.UnknownError, fmt.Errorf("invalid search method: %s", cfg.SearchMethod))
 	}
 
-	gmenu, err := core.NewGMenu(searchMethod, cfg)
-	if err != nil {
-		return model.NewExitError(model.UnknownError, fmt.Errorf("failed to create gmenu: %w", err))
-	}
-
 	if cfg.TerminalMode {
-		return runTerminalMode(gmenu, cfg)
+		return runTerminalMode(searchMethod, cfg)
 	}
 
-	items, err := readItems()
+	// claim the menu ID before reading input or building the window, so an
+	// invocation handed off to a running menu does neither
+	lock, err := core.ClaimInstance(cfg)
 	if err != nil {
+		if errors.Is(err, core.ErrHandedOff) {
+			// the running menu was focused or toggled instead
+			logrus.Info("menu is already running: ", err)
+			return model.NewExitError(model.UserCanceled, nil)
+		}
 		return model.NewExitError(model.UnknownError, err)
 	}
-	if len(items) == 0 {
-		logrus.Error("No items provided through standard input")
-		gmenu.QuitWithCode(model.UnknownError)
-		return model.NewExitError(model.UnknownError, fmt.Errorf("no items provided through standard input"))
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logrus.WithError(err).Error("failed to release instance lock")
+		}
+	}()
+
+	gmenu, err := core.NewGMenu(searchMethod, cfg, core.WithInstanceLock(lock))
+	if err != nil {
+		return model.NewExitError(model.UnknownError, fmt.Errorf("failed to create gmenu: %w", err))
+	}
+
+	// Items are streamed into the open menu unless auto-accept needs all of
+	// them up front to decide whether there is a single match.
+	streaming := hasPipedInput() && !cfg.AutoAccept
+	var items []string
+	if !streaming {
+		items, err = readItems()
+		if err != nil {
+			return model.NewExitError(model.UnknownError, err)
+		}
+		var headers []string
+		headers, items = splitHeaderLines(items, cfg.HeaderLines)
+		gmenu.SetHeaderLines(headers)
+		if len(items) == 0 {
+			logrus.Error("No items provided through standard input")
+			gmenu.QuitWithCode(model.UnknownError)
+			return model.NewExitError(model.UnknownError, errNoItems)
+		}
 	}
 
 	if err := gmenu.SetupMenu(items, cfg.InitialQuery); err != nil {
 		return model.NewExitError(model.UnknownError, fmt.Errorf("failed to setup menu: %w", err))
 	}
+	streamErr := make(chan error, 1)
+	if streaming {
+		go streamInput(gmenu, cfg.HeaderLines, streamErr, func() { gmenu.QuitWithCode(model.UnknownError) })
+	}
 
 	if cfg.AutoAccept {
 		if gmenu.AttemptAutoSelect() {
-			val, err := gmenu.SelectedValue()
+			vals, err := gmenu.SelectedValues()
 			if err != nil {
 				return model.NewExitError(model.UnknownError, fmt.Errorf("auto-select failed to retrieve value: %w", err))
 			}
-			fmt.Println(val.ComputedTitle())
-			return nil
+			cacheSelection(gmenu)
+			return printSelection(cfg, gmenu, model.NoError, vals)
 		}
 		logrus.WithField("matches", gmenu.MatchCount()).
 			Debug("auto-accept conditions not met; falling back to interactive mode")
@@ -137,96 +280,138 @@ func run(cfg *model.Config) error {
 		logrus.WithError(err).Error("run() err")
 		return model.NewExitError(model.UnknownError, err)
 	}
-	if gmenu.GetExitCode() != model.NoError {
-		logrus.Trace("Quitting gmenu with code: ", gmenu.GetExitCode())
-		return model.NewExitError(gmenu.GetExitCode(), nil)
+	select {
+	case err := <-streamErr:
+		return model.NewExitError(model.UnknownError, err)
+	default:
 	}
-	val, err := gmenu.SelectedValue()
+	code := gmenu.GetExitCode()
+	if !code.IsAccept() {
+		logrus.Trace("Quitting gmenu with code: ", code)
+		if err := printSelection(cfg, gmenu, code, nil); err != nil {
+			logrus.WithError(err).Error("failed to print selection")
+		}
+		return model.NewExitError(code, nil)
+	}
+	vals, err := gmenu.SelectedValues()
 	if err != nil {
 		logrus.Error(err)
 		return model.NewExitError(model.UnknownError, err)
 	}
-	// Output the selected value directly to stdout without any logging
-	fmt.Println(val.ComputedTitle())
-	return nil
+	cacheSelection(gmenu)
+	// Output the selected values directly to stdout without any logging
+	if err := printSelection(cfg, gmenu, code, vals); err != nil {
+		return err
+	}
+	return acceptExitError(code)
 }
 
-func runTerminalMode(gmenu *core.GMenu, cfg *model.Config) error {
-	logrus.Info("Running in terminal mode")
-	items, err := readItems()
-	if err != nil {
-		return model.NewExitError(model.UnknownError, err)
-	}
-	if len(items) == 0 {
-		logrus.Error("No items provided through standard input")
-		return model.NewExitError(model.UnknownError, fmt.Errorf("no items provided through standard input"))
+// acceptExitError returns the error that makes gmenu exit with the code of a
+// custom accept key, or nil for a regular accept.
+func acceptExitError(code model.ExitCode) error {
+	if code == model.NoError {
+		return nil
 	}
-	// reset stdin from non-interactive to interactive.
-	_ = os.Stdin.Close()
-	os.Stdin, _ = os.Open("/dev/tty")
+	return model.NewExitError(code, nil)
+}
+
+// printSelection prints the selection to stdout in the configured output format.
+func printSelection(cfg *model.Config, gmenu *core.GMenu, code model.ExitCode, vals []model.MenuItem) error {
+	return writeSelection(os.Stdout, cfg.OutputFormat, code, gmenu.Query(), vals, gmenu.ItemOutput)
+}
+
+// selectionResult is the document printed with the json output format.
+type selectionResult struct {
+	Reason string            `json:"reason"`
+	Code   int               `json:"code"`
+	Query  string            `json:"query"`
+	Items  []json.RawMessage `json:"items"`
+}
 
-	matcher := func(items []string, query string) []string {
-		var matches []string
-		for _, item := range items {
-			if strings.Contains(strings.ToLower(item), strings.ToLower(query)) {
-				matches = append(matches, item)
+// writeSelection writes the selected items, one per line using output, or as
+// a single JSON document that also carries the exit reason and final query.
+// Text output is only written for selections accepted by the accept key or a
+// custom accept key.
+func writeSelection(w io.Writer, outputFormat string, code model.ExitCode, query string, vals []model.MenuItem, output func(model.MenuItem) string) error {
+	if outputFormat != model.OutputFormatJSON {
+		if !code.IsAccept() {
+			return nil
+		}
+		for _, val := range vals {
+			if _, err := fmt.Fprintln(w, output(val)); err != nil {
+				return err
 			}
 		}
-		return matches
+		return nil
+	}
+	result := selectionResult{
+		Reason: code.Reason(),
+		Code:   int(code),
+		Query:  query,
+		Items:  make([]json.RawMessage, 0, len(vals)),
+	}
+	for _, val := range vals {
+		obj, err := val.JSONObject()
+		if err != nil {
+			return fmt.Errorf("failed to encode item: %w", err)
+		}
+		result.Items = append(result.Items, obj)
 	}
+	return json.NewEncoder(w).Encode(result)
+}
 
-	queryChan := make(chan string, 1)
-	go func() {
-		// ReadUserInputLive() will close queryChan when the user is done.
-		for query := range queryChan {
-			// Clear screen and reset cursor
-			fmt.Print("\033[2J\033[H")
-
-			// Print header
-			logrus.Infof("%s: %s", cfg.Prompt, query)
-			logrus.Info("--------------------------------")
-
-			// Filter and display matching items
-			matchCount := 0
-			for idx, match := range matcher(items, query) {
-				matchCount++
-				if cfg.NoNumericSelection {
-					logrus.Infof("%s", match)
-					continue
-				}
-				logrus.Infof("%d. %s", idx+1, match)
-			}
+// cacheSelection records the accepted selection in the menu cache.
+func cacheSelection(gmenu *core.GMenu) {
+	if err := gmenu.CacheSelectedValue(); err != nil {
+		logrus.WithError(err).Warn("failed to cache selection")
+	}
+}
 
-			if matchCount == 0 {
-				logrus.Info("(no matches)")
-			}
-			logrus.Info("--------------------------------")
-		}
-	}()
+// runTerminalMode runs the menu as a text UI on the controlling terminal.
+// The UI is drawn on /dev/tty so stdout only carries the selection.
+func runTerminalMode(searchMethod core.SearchMethod, cfg *model.Config) error {
+	logrus.Info("Running in terminal mode")
+	if !hasPipedInput() {
+		logrus.Error("No items provided through standard input")
+		return model.NewExitError(model.UnknownError, errNoItems)
+	}
 
-	finalQuery, inputErr := core.ReadUserInputLive(cfg, queryChan)
-	if inputErr != nil {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return model.NewExitError(model.UnknownError, fmt.Errorf("failed to open terminal: %w", err))
+	}
+	defer func() { _ = tty.Close() }()
+
+	menu, err := core.NewTerminalMenu(cfg, searchMethod, nil)
+	if err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	streamErr := make(chan error, 1)
+	go streamInput(menu, cfg.HeaderLines, streamErr, menu.Close)
+	vals, err := menu.Run(tty)
+	select {
+	case err := <-streamErr:
+		return model.NewExitError(model.UnknownError, err)
+	default:
+	}
+	if err != nil {
 		switch {
-		case errors.Is(inputErr, core.ErrTerminalInterrupted):
+		case errors.Is(err, core.ErrTerminalInterrupted):
 			return model.NewExitError(model.NoError, nil)
-		case errors.Is(inputErr, core.ErrTerminalCancelled):
+		case errors.Is(err, core.ErrTerminalCancelled):
+			_ = writeSelection(os.Stdout, cfg.OutputFormat, model.UserCanceled, menu.Query(), nil, menu.ItemOutput)
 			return model.NewExitError(model.UserCanceled, nil)
 		default:
-			return model.NewExitError(model.UnknownError, inputErr)
+			return model.NewExitError(model.UnknownError, err)
 		}
 	}
-
-	matches := matcher(items, finalQuery)
-	if len(matches) == 0 {
+	if len(vals) == 0 {
 		logrus.Info("No matches found")
 		return nil
 	}
-	if len(matches) > 1 {
-		logrus.Info("Multiple matches found. Picking the first one.")
-	}
-	// clear the screen and show result
-	fmt.Print("\033[2J\033[H")
 	// Output the selected value directly to stdout without any logging
-	fmt.Println(matches[0])
-	return nil
+	if err := writeSelection(os.Stdout, cfg.OutputFormat, menu.ExitCode(), menu.Query(), vals, menu.ItemOutput); err != nil {
+		return err
+	}
+	return acceptExitError(menu.ExitCode())
 }
@@ -5,7 +5,9 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/hamidzr/gmenu/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -245,9 +247,12 @@ func TestCLISubcommands(t *testing.T) {
 	assert.NotEmpty(t, cmd.Use)
 	assert.NotEmpty(t, cmd.Short)
 
-	// Check if there are subcommands (currently there should be none)
-	subcommands := cmd.Commands()
-	assert.Empty(t, subcommands, "Root command should not have subcommands currently")
+	// daemon, client and lock are the only subcommands
+	var names []string
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"daemon", "client", "lock"}, names)
 }
 
 // TestCLIUsageAndHelp tests help and usage output
@@ -282,3 +287,125 @@ func TestCLIVersionInfo(t *testing.T) {
 		assert.Equal(t, "bool", versionFlag.Value.Type())
 	}
 }
+
+func TestWriteSelection(t *testing.T) {
+	vals := []model.MenuItem{{Title: "alpha", Value: "a"}, {Title: "beta"}}
+	output := func(item model.MenuItem) string { return item.Title }
+
+	t.Run("text prints one item per line", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeSelection(&buf, model.OutputFormatText, model.NoError, "q", vals, output))
+		assert.Equal(t, "alpha\nbeta\n", buf.String())
+	})
+
+	t.Run("text prints nothing on cancel", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeSelection(&buf, model.OutputFormatText, model.UserCanceled, "q", nil, output))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("json carries reason, query and items", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeSelection(&buf, model.OutputFormatJSON, model.NoError, "al", vals[:1], output))
+		assert.JSONEq(t, `{"reason":"accept","code":0,"query":"al","items":[{"title":"alpha","value":"a"}]}`, buf.String())
+	})
+
+	t.Run("custom accept keys print the selection", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeSelection(&buf, model.OutputFormatText, model.CustomAcceptCode(2), "q", vals[:1], output))
+		assert.Equal(t, "alpha\n", buf.String())
+
+		buf.Reset()
+		require.NoError(t, writeSelection(&buf, model.OutputFormatJSON, model.CustomAcceptCode(2), "al", vals[:1], output))
+		assert.JSONEq(t, `{"reason":"custom-2","code":11,"query":"al","items":[{"title":"alpha","value":"a"}]}`, buf.String())
+	})
+
+	t.Run("json reports cancel", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeSelection(&buf, model.OutputFormatJSON, model.UserCanceled, "x", nil, output))
+		assert.JSONEq(t, `{"reason":"cancel","code":2,"query":"x","items":[]}`, buf.String())
+	})
+}
+
+// recordingSink records the batches, loading states and headers it receives.
+type recordingSink struct {
+	batches [][]string
+	loading []bool
+	headers []string
+}
+
+func (s *recordingSink) AppendItems(items []string)    { s.batches = append(s.batches, items) }
+func (s *recordingSink) SetLoading(loading bool)       { s.loading = append(s.loading, loading) }
+func (s *recordingSink) SetHeaderLines(lines []string) { s.headers = lines }
+
+func TestStreamItems(t *testing.T) {
+	lines := make([]string, streamBatchSize+5)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	sink := &recordingSink{}
+	count, err := streamItems(strings.NewReader(strings.Join(lines, "\n")), sink, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(lines), count)
+	assert.Equal(t, []bool{true, false}, sink.loading)
+
+	var total int
+	for _, batch := range sink.batches {
+		assert.LessOrEqual(t, len(batch), streamBatchSize)
+		total += len(batch)
+	}
+	assert.Equal(t, len(lines), total)
+}
+
+func TestStreamItemsFlushesSlowInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	sink := &recordingSink{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = streamItems(r, sink, 0)
+	}()
+
+	// a partial batch shows up before the producer is done
+	_, err = w.WriteString("first\n")
+	require.NoError(t, err)
+	time.Sleep(3 * streamFlushInterval)
+	_, err = w.WriteString("second\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	<-done
+	assert.Equal(t, [][]string{{"first"}, {"second"}}, sink.batches)
+}
+
+func TestStreamItemsHeaderLines(t *testing.T) {
+	sink := &recordingSink{}
+	count, err := streamItems(strings.NewReader("PID CMD\n1 init\n2 sh\n"), sink, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "header lines aren't counted as items")
+	assert.Equal(t, []string{"PID CMD"}, sink.headers)
+	assert.Equal(t, [][]string{{"1 init", "2 sh"}}, sink.batches)
+
+	// input shorter than the header still shows what it has
+	sink = &recordingSink{}
+	count, err = streamItems(strings.NewReader("PID CMD\n"), sink, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, []string{"PID CMD"}, sink.headers)
+	assert.Empty(t, sink.batches)
+}
+
+func TestSplitHeaderLines(t *testing.T) {
+	lines := []string{"NAME", "a", "b"}
+	headers, items := splitHeaderLines(lines, 1)
+	assert.Equal(t, []string{"NAME"}, headers)
+	assert.Equal(t, []string{"a", "b"}, items)
+
+	headers, items = splitHeaderLines(lines, 0)
+	assert.Empty(t, headers)
+	assert.Equal(t, lines, items)
+
+	headers, items = splitHeaderLines(lines, 5)
+	assert.Equal(t, lines, headers)
+	assert.Empty(t, items)
+}
@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/hamidzr/gmenu/core"
+	"github.com/hamidzr/gmenu/internal/config"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// daemonRequest is sent by the client to show a menu.
+type daemonRequest struct {
+	Config *model.Config `json:"config"`
+	Items  []string      `json:"items"`
+}
+
+// daemonResponse carries the selection as the client prints it, along with
+// the exit code the client exits with.
+type daemonResponse struct {
+	Code   model.ExitCode `json:"code"`
+	Output string         `json:"output"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// defaultSocketPath returns the socket the daemon listens on by default.
+func defaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gmenu.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gmenu-%d.sock", os.Getuid()))
+}
+
+func newDaemonCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Keep a menu running in the background and serve gmenu client requests",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.InitConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			socketPath, _ := cmd.Flags().GetString("socket")
+			return runDaemon(cfg, socketPath)
+		},
+	}
+	cmd.Flags().String("socket", defaultSocketPath(), "Unix socket to listen on")
+	return cmd
+}
+
+func newClientCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "client",
+		Short: "Show a menu through a running gmenu daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.InitConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			socketPath, _ := cmd.Flags().GetString("socket")
+			return runClient(cfg, socketPath)
+		},
+	}
+	cmd.Flags().String("socket", defaultSocketPath(), "Unix socket of the daemon")
+	return cmd
+}
+
+// runDaemon starts the GUI without showing it and serves menus requested
+// over the socket until it is interrupted.
+func runDaemon(cfg *model.Config, socketPath string) error {
+	searchMethod, ok := core.SearchMethods[cfg.SearchMethod]
+	if !ok {
+		return model.NewExitError(model.UnknownError, fmt.Errorf("invalid search method: %s", cfg.SearchMethod))
+	}
+	lock, err := core.ClaimInstance(cfg)
+	if err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logrus.WithError(err).Error("failed to release instance lock")
+		}
+	}()
+	gmenu, err := core.NewGMenu(searchMethod, cfg, core.WithInstanceLock(lock))
+	if err != nil {
+		return model.NewExitError(model.UnknownError, fmt.Errorf("failed to create gmenu: %w", err))
+	}
+	if err := gmenu.SetupMenu(nil, ""); err != nil {
+		return model.NewExitError(model.UnknownError, fmt.Errorf("failed to setup menu: %w", err))
+	}
+
+	listener, err := listenSocket(socketPath)
+	if err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	defer func() { _ = listener.Close() }()
+	logrus.Info("gmenu daemon listening on ", socketPath)
+
+	server := &daemonServer{gmenu: gmenu}
+	go server.serve(listener)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		_ = listener.Close()
+		gmenu.QuitWithCode(model.NoError)
+	}()
+
+	if err := gmenu.RunAppForever(); err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	return nil
+}
+
+// listenSocket listens on the unix socket at path. A socket left behind by a
+// daemon that is gone is replaced, a live one is an error.
+func listenSocket(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("a gmenu daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// daemonServer shows one menu at a time for connected clients.
+type daemonServer struct {
+	gmenu *core.GMenu
+	// mu queues clients while a menu is shown.
+	mu sync.Mutex
+}
+
+func (s *daemonServer) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logrus.WithError(err).Error("failed to accept client")
+			}
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *daemonServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	var req daemonRequest
+	decoder := json.NewDecoder(conn)
+	if err := decoder.Decode(&req); err != nil {
+		logrus.WithError(err).Warn("invalid client request")
+		return
+	}
+	if req.Config == nil {
+		req.Config = model.DefaultConfig()
+	}
+
+	// gone is closed when the client disconnects.
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, io.MultiReader(decoder.Buffered(), conn))
+		close(gone)
+	}()
+
+	s.mu.Lock()
+	select {
+	case <-gone:
+		s.mu.Unlock()
+		return
+	default:
+	}
+	// a client that goes away cancels its menu
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-gone:
+			_ = s.gmenu.SetExitCode(model.UserCanceled)
+			s.gmenu.HideUI()
+		case <-done:
+		}
+	}()
+	resp := s.showMenu(&req)
+	close(done)
+	s.mu.Unlock()
+
+	select {
+	case <-gone:
+		return
+	default:
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logrus.WithError(err).Warn("failed to send response")
+	}
+}
+
+// showMenu shows the requested menu and waits for the selection.
+func (s *daemonServer) showMenu(req *daemonRequest) daemonResponse {
+	cfg := req.Config
+	fail := func(err error) daemonResponse {
+		return daemonResponse{Code: model.UnknownError, Error: err.Error()}
+	}
+	if cfg.OutputFormat != model.OutputFormatText && cfg.OutputFormat != model.OutputFormatJSON {
+		return fail(fmt.Errorf("invalid output format: %s", cfg.OutputFormat))
+	}
+	headers, items := splitHeaderLines(req.Items, cfg.HeaderLines)
+	if len(items) == 0 {
+		return fail(errNoItems)
+	}
+
+	gmenu := s.gmenu
+	if err := gmenu.Reconfigure(cfg); err != nil {
+		return fail(err)
+	}
+	gmenu.Reset(true)
+	gmenu.SetHeaderLines(headers)
+	if err := gmenu.SetupMenu(items, cfg.InitialQuery); err != nil {
+		return fail(fmt.Errorf("failed to setup menu: %w", err))
+	}
+	if !cfg.AutoAccept || !gmenu.AttemptAutoSelect() {
+		if err := gmenu.ShowUI(); err != nil {
+			return fail(fmt.Errorf("failed to show UI: %w", err))
+		}
+		gmenu.WaitForSelection()
+	}
+
+	var output bytes.Buffer
+	code := gmenu.GetExitCode()
+	if !code.IsAccept() {
+		if err := writeSelection(&output, cfg.OutputFormat, code, gmenu.Query(), nil, gmenu.ItemOutput); err != nil {
+			return fail(err)
+		}
+		return daemonResponse{Code: code, Output: output.String()}
+	}
+	vals, err := gmenu.SelectedValues()
+	if err != nil {
+		return fail(err)
+	}
+	cacheSelection(gmenu)
+	if err := writeSelection(&output, cfg.OutputFormat, code, gmenu.Query(), vals, gmenu.ItemOutput); err != nil {
+		return fail(err)
+	}
+	return daemonResponse{Code: code, Output: output.String()}
+}
+
+// runClient sends the items read from standard input to the daemon and prints
+// the selection it returns.
+func runClient(cfg *model.Config, socketPath string) error {
+	items, err := readItems()
+	if err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	if len(items) == 0 {
+		logrus.Error("No items provided through standard input")
+		return model.NewExitError(model.UnknownError, errNoItems)
+	}
+	resp, err := requestMenu(socketPath, daemonRequest{Config: cfg, Items: items})
+	if err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	if resp.Error != "" {
+		return model.NewExitError(resp.Code, errors.New(resp.Error))
+	}
+	if _, err := io.WriteString(os.Stdout, resp.Output); err != nil {
+		return model.NewExitError(model.UnknownError, err)
+	}
+	if resp.Code != model.NoError {
+		return model.NewExitError(resp.Code, nil)
+	}
+	return nil
+}
+
+// requestMenu asks the daemon at socketPath to show a menu and waits for the
+// selection.
+func requestMenu(socketPath string, req daemonRequest) (*daemonResponse, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the gmenu daemon at %s: %w", socketPath, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp daemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/hamidzr/gmenu/core"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quietApp is the fyne test app without theme changes, which it applies on a
+// goroutine of its own that races with the widgets being built.
+type quietApp struct{ fyne.App }
+
+func (a quietApp) Settings() fyne.Settings { return quietSettings{a.App.Settings()} }
+
+type quietSettings struct{ fyne.Settings }
+
+func (quietSettings) SetTheme(fyne.Theme) {}
+
+// daemonTestApp is shared by the daemon tests, as a new test app resets
+// global font caches that an earlier daemon may still be drawing with.
+var daemonTestApp = sync.OnceValue(func() fyne.App { return quietApp{test.NewApp()} })
+
+// startTestDaemon serves a menu backed by the fyne test app on a temporary socket.
+func startTestDaemon(t *testing.T) string {
+	t.Helper()
+	gmenu, err := core.NewGMenuWithApp(daemonTestApp(), core.DirectSearch, model.DefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, gmenu.SetupMenu(nil, ""))
+
+	socketPath := filepath.Join(t.TempDir(), "gmenu.sock")
+	listener, err := listenSocket(socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go (&daemonServer{gmenu: gmenu}).serve(listener)
+	return socketPath
+}
+
+func TestListenSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "gmenu.sock")
+	listener, err := listenSocket(socketPath)
+	require.NoError(t, err)
+
+	_, err = listenSocket(socketPath)
+	assert.Error(t, err, "a live daemon keeps its socket")
+
+	// net.Listener removes the socket on close, so leave one behind by hand
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	listener, err = listenSocket(socketPath)
+	require.NoError(t, err, "a stale socket is replaced")
+	require.NoError(t, listener.Close())
+}
+
+func TestDaemonRoundTrip(t *testing.T) {
+	socketPath := startTestDaemon(t)
+
+	cfg := model.DefaultConfig()
+	cfg.AutoAccept = true
+	resp, err := requestMenu(socketPath, daemonRequest{Config: cfg, Items: []string{"only"}})
+	require.NoError(t, err)
+	assert.Equal(t, model.NoError, resp.Code)
+	assert.Equal(t, "only\n", resp.Output)
+
+	// the next client gets its own config
+	cfg.OutputFormat = model.OutputFormatJSON
+	cfg.InitialQuery = "be"
+	resp, err = requestMenu(socketPath, daemonRequest{Config: cfg, Items: []string{"alpha", "beta"}})
+	require.NoError(t, err)
+	var result selectionResult
+	require.NoError(t, json.Unmarshal([]byte(resp.Output), &result))
+	assert.Equal(t, "accept", result.Reason)
+	assert.Len(t, result.Items, 1)
+
+	cfg.OutputFormat = "yaml"
+	resp, err = requestMenu(socketPath, daemonRequest{Config: cfg, Items: []string{"alpha"}})
+	require.NoError(t, err)
+	assert.Equal(t, model.UnknownError, resp.Code)
+	assert.Contains(t, resp.Error, "invalid output format")
+
+	cfg.OutputFormat = model.OutputFormatText
+	cfg.Preview = "cat {}"
+	resp, err = requestMenu(socketPath, daemonRequest{Config: cfg, Items: []string{"alpha"}})
+	require.NoError(t, err)
+	assert.Equal(t, model.UnknownError, resp.Code)
+	assert.Contains(t, resp.Error, "preview", "settings of the daemon are refused, not dropped")
+}
+
+func TestDaemonCancelsMenuOfDisconnectedClient(t *testing.T) {
+	socketPath := startTestDaemon(t)
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(conn).Encode(daemonRequest{Config: model.DefaultConfig(), Items: []string{"a", "b"}}))
+	time.Sleep(100 * time.Millisecond) // let the menu open
+	require.NoError(t, conn.Close())
+
+	// the daemon is free for the next client
+	cfg := model.DefaultConfig()
+	cfg.AutoAccept = true
+	done := make(chan *daemonResponse)
+	go func() {
+		resp, err := requestMenu(socketPath, daemonRequest{Config: cfg, Items: []string{"next"}})
+		assert.NoError(t, err)
+		done <- resp
+	}()
+	select {
+	case resp := <-done:
+		assert.Equal(t, "next\n", resp.Output)
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not cancel the abandoned menu")
+	}
+}
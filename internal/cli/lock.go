@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/hamidzr/gmenu/core"
+	"github.com/hamidzr/gmenu/internal/config"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/spf13/cobra"
+)
+
+func newLockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Inspect or clear the lock that keeps a second gmenu from starting",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the lock of the menu ID and the process holding it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			menuID, err := lockMenuID(cmd)
+			if err != nil {
+				return err
+			}
+			status, err := core.InstanceLockStatus(menuID)
+			if err != nil {
+				return model.NewExitError(model.UnknownError, err)
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", status.Path, status)
+			return err
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove the lock of the menu ID unless a running gmenu holds it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			menuID, err := lockMenuID(cmd)
+			if err != nil {
+				return err
+			}
+			if err := core.ClearInstanceLock(menuID); err != nil {
+				return model.NewExitError(model.UnknownError, err)
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s: cleared\n", core.LockPath(menuID))
+			return err
+		},
+	})
+	return cmd
+}
+
+// lockMenuID returns the menu ID whose lock the lock commands work on.
+func lockMenuID(cmd *cobra.Command) (string, error) {
+	cfg, err := config.InitConfig(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize config: %w", err)
+	}
+	return cfg.MenuID, nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/hamidzr/gmenu/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runLockCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := InitCLI()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(append([]string{"lock"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestLockCommands(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	out, err := runLockCmd(t, "status", "--menu-id", "files")
+	require.NoError(t, err)
+	assert.Contains(t, out, "no lock file")
+
+	lock, err := core.AcquireInstanceLock("files")
+	require.NoError(t, err)
+	out, err = runLockCmd(t, "status", "--menu-id", "files")
+	require.NoError(t, err)
+	assert.Contains(t, out, "locked by running process")
+	_, err = runLockCmd(t, "clear", "--menu-id", "files")
+	assert.ErrorIs(t, err, core.ErrInstanceLocked)
+	require.NoError(t, lock.Release())
+
+	// a lock file left by a crash is cleared
+	require.NoError(t, os.WriteFile(core.LockPath("files"), []byte("999999999\n"), 0o600))
+	out, err = runLockCmd(t, "status", "--menu-id", "files")
+	require.NoError(t, err)
+	assert.Contains(t, out, "stale")
+	out, err = runLockCmd(t, "clear", "--menu-id", "files")
+	require.NoError(t, err)
+	assert.Contains(t, out, "cleared")
+	assert.NoFileExists(t, core.LockPath("files"))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/hamidzr/gmenu/model"
 	"github.com/hamidzr/gmenu/pkg/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 )
@@ -64,7 +65,7 @@ func InitConfig(cmd *cobra.Command) (*model.Config, error) {
 	registerConfigKeyAliases(v)
 
 	// bind CLI flags to viper (highest priority)
-	if err := v.BindPFlags(cmd.Flags()); err != nil {
+	if err := bindFlagKeys(v, cmd); err != nil {
 		return nil, fmt.Errorf("error binding flags: %w", err)
 	}
 
@@ -74,13 +75,27 @@ func InitConfig(cmd *cobra.Command) (*model.Config, error) {
 		"menu_id",
 		"search_method",
 		"preserve_order",
+		"ignore_diacritics",
 		"auto_accept",
 		"terminal_mode",
 		"no_numeric_selection",
+		"show_score",
 		"min_width",
 		"min_height",
 		"max_width",
 		"max_height",
+		"max_results",
+		"input_format",
+		"output_format",
+		"with_nth",
+		"output_nth",
+		"group_nth",
+		"group_order",
+		"header_lines",
+		"preview_position",
+		"mark_key",
+		"on_conflict",
+		"state_dir",
 	}
 
 	for _, flag := range flags {
@@ -96,6 +111,22 @@ func InitConfig(cmd *cobra.Command) (*model.Config, error) {
 	return &config, nil
 }
 
+// bindFlagKeys binds every flag to the config key of the same name, or to
+// the nested key it sets.
+func bindFlagKeys(v *viper.Viper, cmd *cobra.Command) error {
+	var bindErr error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		key := flag.Name
+		if nested, ok := nestedFlagKeys[key]; ok {
+			key = nested
+		}
+		if err := v.BindPFlag(key, flag); err != nil && bindErr == nil {
+			bindErr = err
+		}
+	})
+	return bindErr
+}
+
 // InitConfigFile generates and saves a default config file to the appropriate location
 func InitConfigFile(menuID string) (string, error) {
 	// get the preferred config directory
@@ -130,7 +161,7 @@ func InitConfigFile(menuID string) (string, error) {
 	header := `# gmenu configuration file
 # Generated automatically - customize as needed
 #
-# Search method options: direct, fuzzy, fuzzy1, fuzzy3, default
+# Search method options: direct, fuzzy, fuzzy1, fuzzy3, default, extended
 # Window dimensions: set min_width/min_height and optional max_width/max_height
 #
 
@@ -287,6 +287,96 @@ initialQuery: "camel"
 	assert.Contains(t, err.Error(), "initialQuery")
 }
 
+func TestInitConfigKeybindings(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".config", "gmenu")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	configPath := filepath.Join(configDir, "config.yaml")
+
+	configContent := `
+keybindings:
+  ctrl+j: down
+  ctrl+k: up
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o644))
+	cmd := &cobra.Command{Use: "gmenu"}
+	BindFlags(cmd)
+	cfg, err := InitConfig(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ctrl+j": "down", "ctrl+k": "up"}, cfg.Keybindings)
+
+	configContent = `
+keybindings:
+  ctrl+j: jump
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o644))
+	_, err = InitConfig(cmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jump")
+
+	// malformed chords fail when the config loads, naming the file
+	for _, content := range []string{"keybindings:\n  hyper+j: down\n", "mark_key: ctrl+\n"} {
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+		_, err = InitConfig(cmd)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), "config.yaml", content)
+	}
+}
+
+func TestInitConfigTheme(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("GMENU_THEME_VARIANT", "light")
+
+	configDir := filepath.Join(tmpDir, ".config", "gmenu")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	configPath := filepath.Join(configDir, "config.yaml")
+
+	configContent := `
+theme:
+  name: nord
+  font_size: 18
+  dark:
+    match: "#88c0d0"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o644))
+	cmd := &cobra.Command{Use: "gmenu"}
+	BindFlags(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--theme", "solarized"}))
+	cfg, err := InitConfig(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, model.Theme{
+		Name:     "solarized",
+		Variant:  "light",
+		FontSize: 18,
+		Dark:     model.ThemeColors{Match: "#88c0d0"},
+	}, cfg.Theme)
+
+	configContent = `
+theme:
+  dark:
+    backgrund: "#000000"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o644))
+	_, err = InitConfig(cmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backgrund")
+
+	configContent = `
+theme:
+  dark:
+    background: black
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o644))
+	_, err = InitConfig(cmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "black")
+}
+
 // TestConfigValidation tests configuration validation
 func TestConfigValidation(t *testing.T) {
 	testCases := []struct {
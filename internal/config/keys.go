@@ -8,6 +8,9 @@ import (
 
 	yamlv3 "gopkg.in/yaml.v3"
 
+	"github.com/hamidzr/gmenu/core"
+	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/pkg/config"
 	"github.com/spf13/viper"
 )
 
@@ -22,14 +25,34 @@ var configKeyVariants = []configKeyVariant{
 	{canonical: "menu_id", camel: "menuId"},
 	{canonical: "search_method", camel: "searchMethod"},
 	{canonical: "preserve_order", camel: "preserveOrder"},
+	{canonical: "ignore_diacritics", camel: "ignoreDiacritics"},
 	{canonical: "initial_query", camel: "initialQuery"},
 	{canonical: "auto_accept", camel: "autoAccept"},
 	{canonical: "terminal_mode", camel: "terminalMode"},
 	{canonical: "no_numeric_selection", camel: "noNumericSelection"},
+	{canonical: "show_score", camel: "showScore"},
 	{canonical: "min_width", camel: "minWidth"},
 	{canonical: "min_height", camel: "minHeight"},
 	{canonical: "max_width", camel: "maxWidth"},
 	{canonical: "max_height", camel: "maxHeight"},
+	{canonical: "max_results", camel: "maxResults"},
+	{canonical: "input_format", camel: "inputFormat"},
+	{canonical: "output_format", camel: "outputFormat"},
+	{canonical: "delimiter"},
+	{canonical: "with_nth", camel: "withNth"},
+	{canonical: "output_nth", camel: "outputNth"},
+	{canonical: "group_nth", camel: "groupNth"},
+	{canonical: "group_order", camel: "groupOrder"},
+	{canonical: "header_lines", camel: "headerLines"},
+	{canonical: "message"},
+	{canonical: "preview"},
+	{canonical: "preview_position", camel: "previewPosition"},
+	{canonical: "multi"},
+	{canonical: "mark_key", camel: "markKey"},
+	{canonical: "keybindings"},
+	{canonical: "theme"},
+	{canonical: "on_conflict", camel: "onConflict"},
+	{canonical: "state_dir", camel: "stateDir"},
 	{canonical: "accept_custom_selection", camel: "acceptCustomSelection"},
 }
 
@@ -83,11 +106,68 @@ func validateConfigFileKeys(configPath string) error {
 			return fmt.Errorf("config file %s contains both %q (%s) and %q (%s); use one naming style for %q", displayPath, previous, keyStyle(previous), key, keyStyle(key), canonical)
 		}
 		seen[canonical] = key
+		switch canonical {
+		case "keybindings":
+			if err := validateKeybindings(raw[key]); err != nil {
+				return fmt.Errorf("config file %s: %w", displayPath, err)
+			}
+		case "mark_key":
+			if chord, ok := raw[key].(string); ok && chord != "" {
+				if err := core.ValidateKeyChord(chord); err != nil {
+					return fmt.Errorf("config file %s: invalid mark_key: %w", displayPath, err)
+				}
+			}
+		case "theme":
+			if err := validateTheme(raw[key]); err != nil {
+				return fmt.Errorf("config file %s: %w", displayPath, err)
+			}
+		}
 	}
 
 	return nil
 }
 
+// validateKeybindings checks that the keybindings section maps valid key
+// chords to known actions.
+func validateKeybindings(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bindings, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("keybindings must map key chords to actions")
+	}
+	for chord, action := range bindings {
+		if err := core.ValidateKeyChord(chord); err != nil {
+			return fmt.Errorf("invalid keybinding: %w", err)
+		}
+		name, ok := action.(string)
+		if !ok {
+			return fmt.Errorf("keybinding %q must be an action name", chord)
+		}
+		if _, err := model.ParseKeyAction(name); err != nil {
+			return fmt.Errorf("keybinding %q: %w", chord, err)
+		}
+	}
+	return nil
+}
+
+// validateTheme checks the theme section for unknown keys and bad values.
+func validateTheme(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	data, err := yamlv3.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
+	theme, err := config.ParseTheme(data)
+	if err != nil {
+		return err
+	}
+	return theme.Validate()
+}
+
 func keyStyle(key string) string {
 	if strings.Contains(key, "_") {
 		return "snake_case"
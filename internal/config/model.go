@@ -18,16 +18,40 @@ func BindFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringP("menu-id", "m", defaults.MenuID, "Menu ID")
 	cmd.PersistentFlags().StringP("search-method", "s", defaults.SearchMethod, "Search method")
 	cmd.PersistentFlags().BoolP("preserve-order", "o", defaults.PreserveOrder, "Preserve the order of the input items")
+	cmd.PersistentFlags().Bool("ignore-diacritics", defaults.IgnoreDiacritics, "Match letters regardless of accents, e.g. e matches é")
 	cmd.PersistentFlags().Bool("auto-accept", defaults.AutoAccept, "Auto accept if there's only a single match")
 	cmd.PersistentFlags().Bool("terminal", defaults.TerminalMode, "Run in terminal-only mode without GUI")
 	cmd.PersistentFlags().Bool("no-numeric-selection", defaults.NoNumericSelection, "Disable numeric selection")
+	cmd.PersistentFlags().Bool("show-score", defaults.ShowScore, "Show the search score of every item in the GUI")
 	cmd.PersistentFlags().Float32("min-width", defaults.MinWidth, "Minimum window width")
 	cmd.PersistentFlags().Float32("min-height", defaults.MinHeight, "Minimum window height")
 	cmd.PersistentFlags().Float32("max-width", defaults.MaxWidth, "Maximum window width")
 	cmd.PersistentFlags().Float32("max-height", defaults.MaxHeight, "Maximum window height")
+	cmd.PersistentFlags().Int("max-results", defaults.MaxResults, "Maximum number of results to list (0 for all matches)")
+	cmd.PersistentFlags().String("input-format", defaults.InputFormat, "Input format: text or jsonl (one JSON object per line)")
+	cmd.PersistentFlags().String("output-format", defaults.OutputFormat, "Output format: text or json (selected objects and exit reason)")
+	cmd.PersistentFlags().StringP("delimiter", "d", defaults.Delimiter, "Field delimiter for structured input (default: whitespace)")
+	cmd.PersistentFlags().String("with-nth", defaults.WithNth, "Fields to display and search, e.g. 2 or 2.. or 1,3")
+	cmd.PersistentFlags().String("output-nth", defaults.OutputNth, "Fields to print on accept (default: the whole line)")
+	cmd.PersistentFlags().String("group-nth", defaults.GroupNth, "Fields that name the group of an item, shown as section headers")
+	cmd.PersistentFlags().String("group-order", defaults.GroupOrder, "Order of grouped matches: together or interleave")
+	cmd.PersistentFlags().Int("header-lines", defaults.HeaderLines, "Number of leading input lines to show as fixed headers that are never searched or selected")
+	cmd.PersistentFlags().String("message", defaults.Message, "Message to show under the search box")
+	cmd.PersistentFlags().String("preview", defaults.Preview, "Command whose output previews the selected item; {} is replaced by the item")
+	cmd.PersistentFlags().String("preview-position", defaults.PreviewPosition, "Where to show the preview pane: right or bottom")
+	cmd.PersistentFlags().Bool("multi", defaults.Multi, "Allow marking multiple items and print all of them")
+	cmd.PersistentFlags().String("mark-key", defaults.MarkKey, "Key chord that toggles marks in multi-select mode")
+	cmd.PersistentFlags().String("on-conflict", defaults.OnConflict, "When the menu ID is already running: fail, focus-existing, replace or toggle")
+	cmd.PersistentFlags().String("state-dir", defaults.StateDir, "Directory to keep history in (default: $XDG_STATE_HOME/gmenu)")
+	cmd.PersistentFlags().String("theme", defaults.Theme.Name, "Theme to load from themes/<name>.yaml in the config directory")
 	cmd.PersistentFlags().Bool("init-config", false, "Generate and save default config file")
 }
 
+// nestedFlagKeys maps flags to the nested config keys they set.
+var nestedFlagKeys = map[string]string{
+	"theme": "theme.name",
+}
+
 // SetViperDefaults sets default values in viper configuration
 func SetViperDefaults(v *viper.Viper) {
 	defaults := model.DefaultConfig()
@@ -36,20 +60,53 @@ func SetViperDefaults(v *viper.Viper) {
 	v.SetDefault("menu_id", defaults.MenuID)
 	v.SetDefault("search_method", defaults.SearchMethod)
 	v.SetDefault("preserve_order", defaults.PreserveOrder)
+	v.SetDefault("ignore_diacritics", defaults.IgnoreDiacritics)
 	v.SetDefault("initial_query", defaults.InitialQuery)
 	v.SetDefault("auto_accept", defaults.AutoAccept)
 	v.SetDefault("terminal_mode", defaults.TerminalMode)
 	v.SetDefault("no_numeric_selection", defaults.NoNumericSelection)
+	v.SetDefault("show_score", defaults.ShowScore)
 	v.SetDefault("min_width", defaults.MinWidth)
 	v.SetDefault("min_height", defaults.MinHeight)
 	v.SetDefault("max_width", defaults.MaxWidth)
 	v.SetDefault("max_height", defaults.MaxHeight)
+	v.SetDefault("max_results", defaults.MaxResults)
+	v.SetDefault("input_format", defaults.InputFormat)
+	v.SetDefault("output_format", defaults.OutputFormat)
+	v.SetDefault("delimiter", defaults.Delimiter)
+	v.SetDefault("with_nth", defaults.WithNth)
+	v.SetDefault("output_nth", defaults.OutputNth)
+	v.SetDefault("group_nth", defaults.GroupNth)
+	v.SetDefault("group_order", defaults.GroupOrder)
+	v.SetDefault("header_lines", defaults.HeaderLines)
+	v.SetDefault("message", defaults.Message)
+	v.SetDefault("preview", defaults.Preview)
+	v.SetDefault("preview_position", defaults.PreviewPosition)
+	v.SetDefault("multi", defaults.Multi)
+	v.SetDefault("mark_key", defaults.MarkKey)
+	v.SetDefault("keybindings", defaults.Keybindings)
+	// theme keys are set one by one so environment variables can reach them
+	v.SetDefault("theme.name", defaults.Theme.Name)
+	v.SetDefault("theme.variant", defaults.Theme.Variant)
+	v.SetDefault("theme.font", defaults.Theme.Font)
+	v.SetDefault("theme.font_size", defaults.Theme.FontSize)
+	for variant, colors := range map[string]model.ThemeColors{"light": defaults.Theme.Light, "dark": defaults.Theme.Dark} {
+		prefix := "theme." + variant + "."
+		v.SetDefault(prefix+"background", colors.Background)
+		v.SetDefault(prefix+"foreground", colors.Foreground)
+		v.SetDefault(prefix+"selection", colors.Selection)
+		v.SetDefault(prefix+"stripe", colors.Stripe)
+		v.SetDefault(prefix+"hover", colors.Hover)
+		v.SetDefault(prefix+"match", colors.Match)
+	}
+	v.SetDefault("on_conflict", defaults.OnConflict)
+	v.SetDefault("state_dir", defaults.StateDir)
 	v.SetDefault("accept_custom_selection", defaults.AcceptCustomSelection)
 }
 
 // SetViperEnvSettings configures viper environment variable settings
 func SetViperEnvSettings(v *viper.Viper) {
 	v.SetEnvPrefix("GMENU")
-	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	v.AutomaticEnv()
 }
@@ -10,19 +10,76 @@ type Config struct {
 	MenuID             string  `mapstructure:"menu_id" yaml:"menu_id"`
 	SearchMethod       string  `mapstructure:"search_method" yaml:"search_method"`
 	PreserveOrder      bool    `mapstructure:"preserve_order" yaml:"preserve_order"`
+	IgnoreDiacritics   bool    `mapstructure:"ignore_diacritics" yaml:"ignore_diacritics"`
 	InitialQuery       string  `mapstructure:"initial_query" yaml:"initial_query"`
 	AutoAccept         bool    `mapstructure:"auto_accept" yaml:"auto_accept"`
 	TerminalMode       bool    `mapstructure:"terminal_mode" yaml:"terminal_mode"`
 	NoNumericSelection bool    `mapstructure:"no_numeric_selection" yaml:"no_numeric_selection"`
+	ShowScore          bool    `mapstructure:"show_score" yaml:"show_score"`
 	MinWidth           float32 `mapstructure:"min_width" yaml:"min_width"`
 	MinHeight          float32 `mapstructure:"min_height" yaml:"min_height"`
 	MaxWidth           float32 `mapstructure:"max_width" yaml:"max_width"`
 	MaxHeight          float32 `mapstructure:"max_height" yaml:"max_height"`
+	MaxResults         int     `mapstructure:"max_results" yaml:"max_results"`
+	// structured input settings
+	InputFormat  string `mapstructure:"input_format" yaml:"input_format"`
+	OutputFormat string `mapstructure:"output_format" yaml:"output_format"`
+	Delimiter    string `mapstructure:"delimiter" yaml:"delimiter"`
+	WithNth      string `mapstructure:"with_nth" yaml:"with_nth"`
+	OutputNth    string `mapstructure:"output_nth" yaml:"output_nth"`
+	// GroupNth picks the fields that name the group of an item.
+	GroupNth string `mapstructure:"group_nth" yaml:"group_nth"`
+	// GroupOrder is GroupOrderTogether or GroupOrderInterleave.
+	GroupOrder string `mapstructure:"group_order" yaml:"group_order"`
+	// HeaderLines is the number of leading input lines shown as fixed
+	// headers instead of items.
+	HeaderLines int `mapstructure:"header_lines" yaml:"header_lines"`
+	// Message is shown under the search box.
+	Message string `mapstructure:"message" yaml:"message"`
+	// preview settings
+	Preview         string `mapstructure:"preview" yaml:"preview"`
+	PreviewPosition string `mapstructure:"preview_position" yaml:"preview_position"`
+	// multi-select settings
+	Multi   bool   `mapstructure:"multi" yaml:"multi"`
+	MarkKey string `mapstructure:"mark_key" yaml:"mark_key"`
+	// Keybindings maps key chords to actions, on top of the default bindings.
+	Keybindings map[string]string `mapstructure:"keybindings" yaml:"keybindings"`
+	// Theme sets the colors and font of the GUI.
+	Theme Theme `mapstructure:"theme" yaml:"theme"`
+	// OnConflict decides what happens when a menu with the same ID is
+	// already running.
+	OnConflict string `mapstructure:"on_conflict" yaml:"on_conflict"`
+	// StateDir overrides where history is kept, one directory per menu ID.
+	// Empty means $XDG_STATE_HOME/gmenu.
+	StateDir string `mapstructure:"state_dir" yaml:"state_dir"`
 
 	// internal settings
 	AcceptCustomSelection bool `mapstructure:"accept_custom_selection" yaml:"accept_custom_selection"`
 }
 
+// Policies for starting a menu whose menu ID is already running.
+const (
+	// OnConflictFail exits with an error.
+	OnConflictFail = "fail"
+	// OnConflictFocus raises the running menu and exits.
+	OnConflictFocus = "focus-existing"
+	// OnConflictReplace cancels the running menu and takes over.
+	OnConflictReplace = "replace"
+	// OnConflictToggle closes the running menu if it is shown, or shows it
+	// if it is hidden, and exits.
+	OnConflictToggle = "toggle"
+)
+
+// Orders of the matches of grouped items.
+const (
+	// GroupOrderTogether lists the matches of each group together, the
+	// groups ordered by their best match.
+	GroupOrderTogether = "together"
+	// GroupOrderInterleave ranks every match on its own and starts a new
+	// section wherever the group changes.
+	GroupOrderInterleave = "interleave"
+)
+
 // DefaultConfig returns a config with default values
 func DefaultConfig() *Config {
 	return &Config{
@@ -31,14 +88,33 @@ func DefaultConfig() *Config {
 		MenuID:                "",
 		SearchMethod:          "fuzzy",
 		PreserveOrder:         false,
+		IgnoreDiacritics:      false,
 		InitialQuery:          "",
 		AutoAccept:            false,
 		TerminalMode:          false,
 		NoNumericSelection:    true,
+		ShowScore:             false,
 		MinWidth:              600,
 		MinHeight:             300,
 		MaxWidth:              1920,
 		MaxHeight:             1080,
+		MaxResults:            0,
+		InputFormat:           InputFormatText,
+		OutputFormat:          OutputFormatText,
+		Delimiter:             "",
+		WithNth:               "",
+		OutputNth:             "",
+		GroupNth:              "",
+		GroupOrder:            GroupOrderTogether,
+		HeaderLines:           0,
+		Message:               "",
+		Preview:               "",
+		PreviewPosition:       "right",
+		Multi:                 false,
+		MarkKey:               "shift+tab",
+		Keybindings:           map[string]string{},
+		OnConflict:            OnConflictFail,
+		StateDir:              "",
 		AcceptCustomSelection: true,
 	}
 }
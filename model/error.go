@@ -15,6 +15,42 @@ func (e ExitCode) Error() string {
 	return e.String()
 }
 
+// Reason returns a short machine readable name for the exit code.
+func (e ExitCode) Reason() string {
+	switch e {
+	case NoError:
+		return "accept"
+	case UserCanceled:
+		return "cancel"
+	}
+	if n, ok := e.CustomAccept(); ok {
+		return string(CustomAcceptAction(n))
+	}
+	return "error"
+}
+
+// CustomAccept returns which custom accept key, counting from 1, ended the
+// menu with this code.
+func (e ExitCode) CustomAccept() (int, bool) {
+	if e < customAcceptBase || e >= customAcceptBase+MaxCustomAccepts {
+		return 0, false
+	}
+	return int(e-customAcceptBase) + 1, true
+}
+
+// IsAccept reports whether the code ends the menu with a selection, either by
+// the accept key or by a custom accept key.
+func (e ExitCode) IsAccept() bool {
+	_, custom := e.CustomAccept()
+	return e == NoError || custom
+}
+
+// CustomAcceptCode returns the exit code of custom accept key n, counting
+// from 1.
+func CustomAcceptCode(n int) ExitCode {
+	return customAcceptBase + ExitCode(n-1)
+}
+
 const (
 	Unset ExitCode = -1
 )
@@ -24,6 +60,13 @@ const (
 	UserCanceled
 )
 
+// Custom accept keys exit with codes 10 to 19, like rofi's kb-custom keys.
+const (
+	customAcceptBase ExitCode = 10
+	// MaxCustomAccepts is the number of custom accept keys.
+	MaxCustomAccepts = 10
+)
+
 var (
 	// ErrCustomUserEntry is when the user inputs and pushes an entry through that doesn't exist
 	// and gmenu is not set to accept it.
@@ -0,0 +1,58 @@
+package model
+
+// UngroupedHeader heads the items without a group in a grouped list.
+const UngroupedHeader = "Other"
+
+// ListRow is a row of a list of items: a group header or an item.
+type ListRow struct {
+	// Header is the group name shown by header rows.
+	Header string
+	// Item is the index of the item shown by item rows, -1 for headers.
+	Item int
+}
+
+// IsHeader reports whether the row is a group header.
+func (r ListRow) IsHeader() bool {
+	return r.Item < 0
+}
+
+// GroupRows lays out items as rows with a header before every run of items
+// of the same group. It returns nil when no item has a group, as every row
+// is then an item.
+func GroupRows(items []MenuItem) []ListRow {
+	grouped := false
+	for i := range items {
+		if items[i].Group != "" {
+			grouped = true
+			break
+		}
+	}
+	if !grouped {
+		return nil
+	}
+	rows := make([]ListRow, 0, len(items)+1)
+	for i := range items {
+		if i == 0 || items[i].Group != items[i-1].Group {
+			header := items[i].Group
+			if header == "" {
+				header = UngroupedHeader
+			}
+			rows = append(rows, ListRow{Header: header, Item: -1})
+		}
+		rows = append(rows, ListRow{Item: i})
+	}
+	return rows
+}
+
+// ItemRow returns the index of the row showing item idx.
+func ItemRow(rows []ListRow, idx int) int {
+	if rows == nil {
+		return idx
+	}
+	for i := idx; i < len(rows); i++ {
+		if rows[i].Item == idx {
+			return i
+		}
+	}
+	return idx
+}
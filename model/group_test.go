@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupRows(t *testing.T) {
+	t.Run("ungrouped items have no headers", func(t *testing.T) {
+		items := []MenuItem{{Title: "a"}, {Title: "b"}}
+		assert.Nil(t, GroupRows(items))
+		assert.Equal(t, 1, ItemRow(nil, 1))
+	})
+
+	t.Run("a header starts every run of a group", func(t *testing.T) {
+		items := []MenuItem{
+			{Title: "a", Group: "x"},
+			{Title: "b", Group: "x"},
+			{Title: "c"},
+			{Title: "d", Group: "y"},
+			{Title: "e", Group: "x"},
+		}
+		rows := GroupRows(items)
+		assert.Equal(t, []ListRow{
+			{Header: "x", Item: -1}, {Item: 0}, {Item: 1},
+			{Header: UngroupedHeader, Item: -1}, {Item: 2},
+			{Header: "y", Item: -1}, {Item: 3},
+			{Header: "x", Item: -1}, {Item: 4},
+		}, rows)
+		assert.True(t, rows[0].IsHeader())
+		assert.False(t, rows[1].IsHeader())
+		assert.Equal(t, 6, ItemRow(rows, 3))
+	})
+}
@@ -0,0 +1,192 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fieldRange is an inclusive, 1-based range of fields. Negative indexes count
+// from the last field and 0 marks an open end.
+type fieldRange struct {
+	start int
+	end   int
+}
+
+// FieldSelector picks fields using fzf style expressions such as
+// "1", "2..", "..3", "-1" or "1,3..4". An empty selector picks every field.
+type FieldSelector []fieldRange
+
+// ParseFieldSelector parses a comma separated list of field indexes and ranges.
+func ParseFieldSelector(expr string) (FieldSelector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+	var selector FieldSelector
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		var r fieldRange
+		var err error
+		if startStr, endStr, isRange := strings.Cut(part, ".."); isRange {
+			if r.start, err = parseFieldIndex(startStr, true); err == nil {
+				r.end, err = parseFieldIndex(endStr, true)
+			}
+		} else if r.start, err = parseFieldIndex(part, false); err == nil {
+			r.end = r.start
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid field expression %q: %w", expr, err)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+func parseFieldIndex(s string, allowEmpty bool) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" && allowEmpty {
+		return 0, nil
+	}
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a field index", s)
+	}
+	if idx == 0 {
+		return 0, fmt.Errorf("field indexes start at 1")
+	}
+	return idx, nil
+}
+
+// resolve turns a possibly negative or open index into a 0-based one.
+func resolveFieldIndex(idx, count, open int) int {
+	switch {
+	case idx == 0:
+		return open
+	case idx < 0:
+		return count + idx
+	default:
+		return idx - 1
+	}
+}
+
+// Select returns the selected fields in selector order.
+func (s FieldSelector) Select(fields []string) []string {
+	if len(s) == 0 {
+		return fields
+	}
+	selected := make([]string, 0, len(fields))
+	for _, r := range s {
+		start := max(resolveFieldIndex(r.start, len(fields), 0), 0)
+		end := min(resolveFieldIndex(r.end, len(fields), len(fields)-1), len(fields)-1)
+		for i := start; i <= end; i++ {
+			selected = append(selected, fields[i])
+		}
+	}
+	return selected
+}
+
+// ItemFormat describes how structured input lines are split into fields,
+// which fields are displayed and searched, and which are printed on accept.
+type ItemFormat struct {
+	// InputFormat is either InputFormatText or InputFormatJSONL.
+	InputFormat string
+	// Delimiter separates fields. Empty means runs of whitespace.
+	Delimiter string
+	WithNth   FieldSelector
+	OutputNth FieldSelector
+	// GroupNth picks the fields naming the group of an item. Empty leaves
+	// items ungrouped.
+	GroupNth FieldSelector
+}
+
+// NewItemFormat builds an item format from its CLI/config representation.
+// The delimiter may use Go escapes such as `\t`.
+func NewItemFormat(inputFormat, delimiter, withNth, outputNth, groupNth string) (ItemFormat, error) {
+	var format ItemFormat
+	var err error
+	switch inputFormat {
+	case "", InputFormatText:
+		format.InputFormat = InputFormatText
+	case InputFormatJSONL:
+		format.InputFormat = InputFormatJSONL
+	default:
+		return ItemFormat{}, fmt.Errorf("invalid input format %q: expected %s or %s", inputFormat, InputFormatText, InputFormatJSONL)
+	}
+	format.Delimiter = delimiter
+	if unquoted, err := strconv.Unquote(`"` + delimiter + `"`); err == nil {
+		format.Delimiter = unquoted
+	}
+	if format.WithNth, err = ParseFieldSelector(withNth); err != nil {
+		return ItemFormat{}, fmt.Errorf("invalid with-nth: %w", err)
+	}
+	if format.OutputNth, err = ParseFieldSelector(outputNth); err != nil {
+		return ItemFormat{}, fmt.Errorf("invalid output-nth: %w", err)
+	}
+	if format.GroupNth, err = ParseFieldSelector(groupNth); err != nil {
+		return ItemFormat{}, fmt.Errorf("invalid group-nth: %w", err)
+	}
+	return format, nil
+}
+
+// IsStructured reports whether text lines should be split into fields.
+func (f ItemFormat) IsStructured() bool {
+	return f.Delimiter != "" || len(f.WithNth) > 0 || len(f.OutputNth) > 0 || len(f.GroupNth) > 0
+}
+
+func (f ItemFormat) split(line string) []string {
+	if f.Delimiter == "" {
+		return strings.Fields(line)
+	}
+	return strings.Split(line, f.Delimiter)
+}
+
+func (f ItemFormat) join(fields []string) string {
+	if f.Delimiter == "" {
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(fields, f.Delimiter)
+}
+
+// Parse builds a menu item from an input line. Structured items keep the raw
+// line and its fields, are titled by the WithNth fields and grouped by the
+// GroupNth fields. JSON lines that fail to parse come back as plain items
+// along with the error.
+func (f ItemFormat) Parse(line string) (MenuItem, error) {
+	if f.InputFormat == InputFormatJSONL {
+		item, err := ParseJSONItem(line)
+		if err != nil {
+			return MenuItem{Title: line}, err
+		}
+		return item, nil
+	}
+	if !f.IsStructured() {
+		return MenuItem{Title: line}, nil
+	}
+	fields := f.split(line)
+	item := MenuItem{
+		Title:  f.join(f.WithNth.Select(fields)),
+		Raw:    line,
+		Fields: fields,
+	}
+	if len(f.GroupNth) > 0 {
+		item.Group = f.join(f.GroupNth.Select(fields))
+	}
+	return item, nil
+}
+
+// Output returns the text printed when the item is accepted: the OutputNth
+// fields of structured items, the whole input line when no OutputNth is set,
+// or the item value falling back to its title.
+func (f ItemFormat) Output(item MenuItem) string {
+	if item.Fields == nil {
+		if item.Value != "" {
+			return item.Value
+		}
+		return item.ComputedTitle()
+	}
+	if len(f.OutputNth) == 0 {
+		return item.Raw
+	}
+	return f.join(f.OutputNth.Select(item.Fields))
+}
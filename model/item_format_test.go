@@ -0,0 +1,88 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldSelector(t *testing.T) {
+	fields := []string{"a", "b", "c", "d"}
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{expr: "", expected: []string{"a", "b", "c", "d"}},
+		{expr: "2", expected: []string{"b"}},
+		{expr: "-1", expected: []string{"d"}},
+		{expr: "2..", expected: []string{"b", "c", "d"}},
+		{expr: "..2", expected: []string{"a", "b"}},
+		{expr: "2..-2", expected: []string{"b", "c"}},
+		{expr: "3,1", expected: []string{"c", "a"}},
+		{expr: "9", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := ParseFieldSelector(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, selector.Select(fields))
+		})
+	}
+}
+
+func TestFieldSelectorInvalid(t *testing.T) {
+	for _, expr := range []string{"0", "x", "1..y", "1,,2"} {
+		_, err := ParseFieldSelector(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestItemFormat(t *testing.T) {
+	t.Run("plain lines are untouched", func(t *testing.T) {
+		format, err := NewItemFormat("", "", "", "", "")
+		require.NoError(t, err)
+		item, err := format.Parse("id\tlabel")
+		require.NoError(t, err)
+		assert.Equal(t, MenuItem{Title: "id\tlabel"}, item)
+		assert.Equal(t, "id\tlabel", format.Output(item))
+	})
+
+	t.Run("tab separated id and label", func(t *testing.T) {
+		format, err := NewItemFormat("", `\t`, "2", "1", "")
+		require.NoError(t, err)
+		item, err := format.Parse("42\thuman label")
+		require.NoError(t, err)
+		assert.Equal(t, "human label", item.ComputedTitle())
+		assert.Equal(t, "42\thuman label", item.Key())
+		assert.Equal(t, "42", format.Output(item))
+	})
+
+	t.Run("whole line is printed without output-nth", func(t *testing.T) {
+		format, err := NewItemFormat("", "", "2..", "", "")
+		require.NoError(t, err)
+		item, err := format.Parse("1234  firefox   --new-window")
+		require.NoError(t, err)
+		assert.Equal(t, "firefox --new-window", item.ComputedTitle())
+		assert.Equal(t, "1234  firefox   --new-window", format.Output(item))
+	})
+
+	t.Run("group column", func(t *testing.T) {
+		format, err := NewItemFormat("", "", "3..", "", "2")
+		require.NoError(t, err)
+		item, err := format.Parse("2 [alacritty] main.go (.)")
+		require.NoError(t, err)
+		assert.Equal(t, "main.go (.)", item.ComputedTitle())
+		assert.Equal(t, "[alacritty]", item.Group)
+	})
+
+	t.Run("invalid selectors", func(t *testing.T) {
+		_, err := NewItemFormat("", ",", "0", "", "")
+		assert.Error(t, err)
+		_, err = NewItemFormat("", ",", "", "a", "")
+		assert.Error(t, err)
+		_, err = NewItemFormat("", ",", "", "", "x")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Input formats understood by ItemFormat.
+const (
+	InputFormatText  = "text"
+	InputFormatJSONL = "jsonl"
+)
+
+// Output formats for accepted selections.
+const (
+	OutputFormatText = "text"
+	OutputFormatJSON = "json"
+)
+
+// JSONItem is the JSON Lines representation of a menu item.
+type JSONItem struct {
+	Title string                 `json:"title"`
+	Value string                 `json:"value,omitempty"`
+	Icon  string                 `json:"icon,omitempty"`
+	Score int                    `json:"score,omitempty"`
+	Group string                 `json:"group,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// ParseJSONItem builds a menu item from a single JSON object line.
+// The raw line is kept so the object can be printed back untouched.
+func ParseJSONItem(line string) (MenuItem, error) {
+	var obj JSONItem
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return MenuItem{}, fmt.Errorf("invalid json item: %w", err)
+	}
+	if obj.Title == "" {
+		obj.Title = obj.Value
+	}
+	if obj.Title == "" {
+		return MenuItem{}, errors.New("invalid json item: missing title")
+	}
+	return MenuItem{
+		Title: obj.Title,
+		Value: obj.Value,
+		Icon:  obj.Icon,
+		Score: obj.Score,
+		Group: obj.Group,
+		Meta:  obj.Meta,
+		Raw:   line,
+	}, nil
+}
+
+// JSONObject returns the item as a JSON object. Items read from JSON Lines
+// are returned as they were given, including unknown keys.
+func (m *MenuItem) JSONObject() (json.RawMessage, error) {
+	if m.Raw != "" && m.Fields == nil && json.Valid([]byte(m.Raw)) {
+		return json.RawMessage(m.Raw), nil
+	}
+	return json.Marshal(JSONItem{
+		Title: m.ComputedTitle(),
+		Value: m.Value,
+		Icon:  m.Icon,
+		Score: m.Score,
+		Group: m.Group,
+		Meta:  m.Meta,
+	})
+}
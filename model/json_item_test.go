@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONItem(t *testing.T) {
+	line := `{"title":"Firefox","value":"firefox --new-window","icon":"web","score":3,"group":"apps","meta":{"pid":42},"extra":true}`
+	item, err := ParseJSONItem(line)
+	require.NoError(t, err)
+	assert.Equal(t, "Firefox", item.ComputedTitle())
+	assert.Equal(t, "firefox --new-window", item.Value)
+	assert.Equal(t, "web", item.Icon)
+	assert.Equal(t, 3, item.Score)
+	assert.Equal(t, "apps", item.Group)
+	assert.Equal(t, map[string]interface{}{"pid": float64(42)}, item.Meta)
+	assert.Equal(t, line, item.Key())
+
+	obj, err := item.JSONObject()
+	require.NoError(t, err)
+	assert.JSONEq(t, line, string(obj))
+}
+
+func TestParseJSONItemTitleFallsBackToValue(t *testing.T) {
+	item, err := ParseJSONItem(`{"value":"only-value"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "only-value", item.ComputedTitle())
+}
+
+func TestParseJSONItemInvalid(t *testing.T) {
+	for _, line := range []string{`not json`, `{"icon":"x"}`, `["a"]`} {
+		_, err := ParseJSONItem(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestItemFormatJSONL(t *testing.T) {
+	format, err := NewItemFormat(InputFormatJSONL, "", "", "", "")
+	require.NoError(t, err)
+
+	item, err := format.Parse(`{"title":"Open","value":"open.sh"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Open", item.ComputedTitle())
+	assert.Equal(t, "open.sh", format.Output(item))
+
+	// malformed lines degrade to plain items
+	item, err = format.Parse("plain line")
+	assert.Error(t, err)
+	assert.Equal(t, "plain line", item.ComputedTitle())
+
+	_, err = NewItemFormat("yaml", "", "", "", "")
+	assert.Error(t, err)
+}
+
+func TestMenuItemJSONObject(t *testing.T) {
+	item := MenuItem{Title: "plain"}
+	obj, err := item.JSONObject()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"plain"}`, string(obj))
+}
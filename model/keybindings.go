@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyAction names what a key chord does in the menu.
+type KeyAction string
+
+// Actions that key chords can be bound to.
+const (
+	ActionUp         KeyAction = "up"
+	ActionDown       KeyAction = "down"
+	ActionPageUp     KeyAction = "page-up"
+	ActionPageDown   KeyAction = "page-down"
+	ActionFirst      KeyAction = "first"
+	ActionLast       KeyAction = "last"
+	ActionAccept     KeyAction = "accept"
+	ActionCancel     KeyAction = "cancel"
+	ActionClearQuery KeyAction = "clear-query"
+	ActionToggleMark KeyAction = "toggle-mark"
+	// ActionHistoryPrev and ActionHistoryNext walk the queries of earlier
+	// selections of the menu ID.
+	ActionHistoryPrev KeyAction = "history-prev"
+	ActionHistoryNext KeyAction = "history-next"
+	// ActionHistorySearch replaces the query with the newest earlier query
+	// containing it, and with older ones when repeated.
+	ActionHistorySearch KeyAction = "history-search"
+	// ActionNone unbinds a chord from its default action.
+	ActionNone KeyAction = "none"
+)
+
+// customAcceptPrefix starts the names of the custom accept actions,
+// custom-1 to custom-10.
+const customAcceptPrefix = "custom-"
+
+// CustomAcceptAction returns the action of custom accept key n, counting
+// from 1.
+func CustomAcceptAction(n int) KeyAction {
+	return KeyAction(customAcceptPrefix + strconv.Itoa(n))
+}
+
+// CustomAccept returns which custom accept key the action is, if any.
+func (a KeyAction) CustomAccept() (int, bool) {
+	digits, ok := strings.CutPrefix(string(a), customAcceptPrefix)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || n > MaxCustomAccepts || CustomAcceptAction(n) != a {
+		return 0, false
+	}
+	return n, true
+}
+
+var keyActions = map[KeyAction]struct{}{
+	ActionUp:            {},
+	ActionDown:          {},
+	ActionPageUp:        {},
+	ActionPageDown:      {},
+	ActionFirst:         {},
+	ActionLast:          {},
+	ActionAccept:        {},
+	ActionCancel:        {},
+	ActionClearQuery:    {},
+	ActionToggleMark:    {},
+	ActionHistoryPrev:   {},
+	ActionHistoryNext:   {},
+	ActionHistorySearch: {},
+	ActionNone:          {},
+}
+
+// ParseKeyAction validates an action name.
+func ParseKeyAction(name string) (KeyAction, error) {
+	action := KeyAction(name)
+	if _, ok := action.CustomAccept(); ok {
+		return action, nil
+	}
+	if _, ok := keyActions[action]; !ok {
+		return "", fmt.Errorf("unknown key action %q, expected one of %v", name, KeyActions())
+	}
+	return action, nil
+}
+
+// KeyActions returns the names of all actions in sorted order, followed by
+// the custom accept actions.
+func KeyActions() []string {
+	names := make([]string, 0, len(keyActions)+MaxCustomAccepts)
+	for action := range keyActions {
+		names = append(names, string(action))
+	}
+	sort.Strings(names)
+	for n := 1; n <= MaxCustomAccepts; n++ {
+		names = append(names, string(CustomAcceptAction(n)))
+	}
+	return names
+}
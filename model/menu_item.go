@@ -13,6 +13,27 @@ type MenuItem struct {
 	AType *GmenuSerializable // why a ptr
 	Score int
 	Icon  string // optional icon identifier
+	// Value is printed instead of the title on accept when set.
+	Value string
+	// Group is an optional group name.
+	Group string
+	// Meta holds arbitrary metadata passed through from JSON input.
+	Meta map[string]interface{}
+	// Raw is the original input line of structured items.
+	Raw string
+	// Fields holds the delimiter separated fields of Raw.
+	Fields []string
+	// Matches holds the title ranges matched by the last search.
+	Matches []MatchRange
+	// Search is the title prepared for searching, set when a menu adds the
+	// item.
+	Search *SearchText
+}
+
+// MatchRange is a half-open range of rune indexes in the computed title.
+type MatchRange struct {
+	Start int
+	End   int
 }
 
 // ComputedTitle returns the title of the menu item.
@@ -26,6 +47,15 @@ func (m *MenuItem) ComputedTitle() string {
 	return ""
 }
 
+// Key identifies the item: the raw input line for structured items and the
+// computed title otherwise.
+func (m *MenuItem) Key() string {
+	if m.Raw != "" {
+		return m.Raw
+	}
+	return m.ComputedTitle()
+}
+
 // Serialize implements GmenuSerializable for MenuItem.
 // CHECK: is it accurate? why do we have the separation here.
 // func (m MenuItem) Serialize() string {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestConfigStruct tests the Config struct
@@ -164,6 +165,40 @@ func TestExitCodeValues(t *testing.T) {
 	assert.Equal(t, 2, int(UserCanceled))
 }
 
+// TestCustomAcceptExitCodes tests the exit codes of the custom accept keys
+func TestCustomAcceptExitCodes(t *testing.T) {
+	assert.Equal(t, ExitCode(10), CustomAcceptCode(1))
+	assert.Equal(t, ExitCode(19), CustomAcceptCode(MaxCustomAccepts))
+
+	n, ok := CustomAcceptCode(3).CustomAccept()
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "custom-3", CustomAcceptCode(3).Reason())
+	_, ok = ExitCode(20).CustomAccept()
+	assert.False(t, ok)
+
+	assert.True(t, NoError.IsAccept())
+	assert.True(t, CustomAcceptCode(1).IsAccept())
+	assert.False(t, UserCanceled.IsAccept())
+	assert.False(t, UnknownError.IsAccept())
+}
+
+// TestCustomAcceptActions tests parsing of the custom accept actions
+func TestCustomAcceptActions(t *testing.T) {
+	action, err := ParseKeyAction("custom-10")
+	require.NoError(t, err)
+	n, ok := action.CustomAccept()
+	assert.True(t, ok)
+	assert.Equal(t, 10, n)
+
+	for _, name := range []string{"custom-0", "custom-11", "custom-01", "custom-x"} {
+		_, err := ParseKeyAction(name)
+		assert.Error(t, err, name)
+	}
+	_, ok = ActionAccept.CustomAccept()
+	assert.False(t, ok)
+}
+
 // TestLoadingItem tests the LoadingItem constant
 func TestLoadingItem(t *testing.T) {
 	assert.Equal(t, "Loading", LoadingItem.Title)
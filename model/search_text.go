@@ -0,0 +1,134 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchText is the title of an item prepared for searching. Menus build it
+// once per item so searches don't redo the work on every keystroke.
+type SearchText struct {
+	// Text is the title searches compare: the title itself, or the title
+	// without diacritics when they are ignored. Its runes line up with the
+	// runes of the title.
+	Text string
+	// Lower is Text case folded rune by rune, so rune indexes of Lower are
+	// rune indexes of the title.
+	Lower string
+	// Runes holds the runes of Lower when the title isn't ASCII. Byte
+	// offsets of Lower are rune indexes otherwise.
+	Runes []rune
+	// Chars is the CharBitmap of Lower.
+	Chars uint64
+}
+
+// NewSearchText prepares title for searching, dropping the diacritics of its
+// letters when ignoreDiacritics is set.
+func NewSearchText(title string, ignoreDiacritics bool) *SearchText {
+	ascii := true
+	for i := 0; i < len(title); i++ {
+		if title[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		lower := strings.ToLower(title)
+		return &SearchText{Text: title, Lower: lower, Chars: CharBitmap(lower)}
+	}
+	text := title
+	if ignoreDiacritics {
+		text = StripDiacritics(title)
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = FoldRune(r)
+	}
+	lower := string(runes)
+	return &SearchText{Text: text, Lower: lower, Runes: runes, Chars: CharBitmap(lower)}
+}
+
+// FoldRune returns the rune r is compared as when case is ignored, mapping
+// every case variant of a letter, like Σ, σ and ς, to the same rune.
+func FoldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return r
+	}
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+// FoldCase applies FoldRune to every rune of s.
+func FoldCase(s string) string {
+	return strings.Map(FoldRune, s)
+}
+
+// StripDiacritics replaces letters that decompose into a base letter and
+// combining marks, like é or آ, by their base letter. Other runes, including
+// lone combining marks, are kept so the result has the runes of s.
+func StripDiacritics(s string) string {
+	return strings.Map(stripDiacritic, s)
+}
+
+func stripDiacritic(r rune) rune {
+	if r < utf8.RuneSelf {
+		return r
+	}
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	decomposed := norm.NFD.Properties(buf[:n]).Decomposition()
+	if decomposed == nil {
+		return r
+	}
+	base, size := utf8.DecodeRune(decomposed)
+	for _, mark := range string(decomposed[size:]) {
+		if !unicode.Is(unicode.Mn, mark) {
+			return r
+		}
+	}
+	return base
+}
+
+// MayContain reports whether the text may hold every character of a
+// CharBitmap. A false answer is certain.
+func (t *SearchText) MayContain(chars uint64) bool {
+	return chars&^t.Chars == 0
+}
+
+// CharBitmap sets a bit for every character in s. Letters and digits have a
+// bit of their own, other characters share bits.
+func CharBitmap(s string) uint64 {
+	var chars uint64
+	for _, r := range s {
+		chars |= 1 << charBit(r)
+	}
+	return chars
+}
+
+func charBit(r rune) uint {
+	switch {
+	case 'a' <= r && r <= 'z':
+		return uint(r - 'a')
+	case 'A' <= r && r <= 'Z':
+		return uint(r - 'A')
+	case '0' <= r && r <= '9':
+		return 26 + uint(r-'0')
+	case r < utf8.RuneSelf:
+		return 36 + uint(r)%12
+	}
+	return 48 + uint(r)%16
+}
+
+// SearchText returns the prepared title of the item, preparing it with
+// diacritics kept when the item has none.
+func (m *MenuItem) SearchText() *SearchText {
+	if m.Search != nil {
+		return m.Search
+	}
+	return NewSearchText(m.ComputedTitle(), false)
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSearchText(t *testing.T) {
+	t.Run("ascii", func(t *testing.T) {
+		text := NewSearchText("Git-Commit", true)
+		assert.Equal(t, "Git-Commit", text.Text)
+		assert.Equal(t, "git-commit", text.Lower)
+		assert.Nil(t, text.Runes)
+		assert.Equal(t, CharBitmap("git-commit"), text.Chars)
+	})
+
+	t.Run("keeps rune indexes", func(t *testing.T) {
+		text := NewSearchText("Ünïcode", false)
+		assert.Equal(t, "Ünïcode", text.Text)
+		assert.Equal(t, "ünïcode", text.Lower)
+		assert.Equal(t, []rune("ünïcode"), text.Runes)
+	})
+
+	t.Run("ignore diacritics", func(t *testing.T) {
+		text := NewSearchText("Ünïcode Straße", true)
+		assert.Equal(t, "Unicode Straße", text.Text)
+		assert.Equal(t, "unicode straße", text.Lower)
+		assert.Len(t, text.Runes, len([]rune("Ünïcode Straße")))
+	})
+}
+
+func TestFoldCase(t *testing.T) {
+	assert.Equal(t, "ärger", FoldCase("ÄRGER"))
+	assert.Equal(t, FoldCase("σ"), FoldCase("ς"))
+	assert.Equal(t, FoldCase("σ"), FoldCase("Σ"))
+	assert.Equal(t, "k", FoldCase("K")) // Kelvin sign
+	assert.Equal(t, "فارسی", FoldCase("فارسی"))
+}
+
+func TestStripDiacritics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "café", expected: "cafe"},
+		{input: "Müller", expected: "Muller"},
+		{input: "ǖ", expected: "u"},
+		{input: "آب", expected: "اب"},
+		{input: "Straße", expected: "Straße"},
+		{input: "한국", expected: "한국"},
+		// lone combining marks are kept so runes still line up
+		{input: "é", expected: "é"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, StripDiacritics(tt.input), tt.input)
+	}
+}
+
+func TestSearchTextMayContain(t *testing.T) {
+	text := NewSearchText("alpha-2", false)
+	assert.True(t, text.MayContain(CharBitmap("ph2")))
+	assert.True(t, text.MayContain(CharBitmap("")))
+	assert.False(t, text.MayContain(CharBitmap("b")))
+	assert.False(t, text.MayContain(CharBitmap("3")))
+}
+
+func TestMenuItemSearchText(t *testing.T) {
+	item := MenuItem{Title: "Alpha"}
+	assert.Equal(t, "alpha", item.SearchText().Lower)
+	prepared := &SearchText{Lower: "prepared"}
+	item.Search = prepared
+	assert.Same(t, prepared, item.SearchText())
+}
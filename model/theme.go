@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Theme variants that can be forced instead of following the system.
+const (
+	ThemeVariantLight = "light"
+	ThemeVariantDark  = "dark"
+)
+
+// Theme configures the colors and font of the GUI. Empty fields keep the
+// defaults.
+type Theme struct {
+	// Name loads themes/<name>.yaml from the config directory. The other
+	// fields override the ones set by the named theme.
+	Name string `mapstructure:"name" yaml:"name,omitempty"`
+	// Variant forces the light or dark colors. Empty follows the system.
+	Variant string `mapstructure:"variant" yaml:"variant,omitempty"`
+	// Font is a TTF or OTF font file used for all text.
+	Font     string      `mapstructure:"font" yaml:"font,omitempty"`
+	FontSize float32     `mapstructure:"font_size" yaml:"font_size,omitempty"`
+	Light    ThemeColors `mapstructure:"light" yaml:"light,omitempty"`
+	Dark     ThemeColors `mapstructure:"dark" yaml:"dark,omitempty"`
+}
+
+// ThemeColors are the colors of one theme variant, written as #rgb, #rrggbb
+// or #rrggbbaa.
+type ThemeColors struct {
+	Background string `mapstructure:"background" yaml:"background,omitempty"`
+	Foreground string `mapstructure:"foreground" yaml:"foreground,omitempty"`
+	Selection  string `mapstructure:"selection" yaml:"selection,omitempty"`
+	Stripe     string `mapstructure:"stripe" yaml:"stripe,omitempty"`
+	Hover      string `mapstructure:"hover" yaml:"hover,omitempty"`
+	Match      string `mapstructure:"match" yaml:"match,omitempty"`
+}
+
+// Overlay returns t with the fields that are set in over replaced.
+func (t Theme) Overlay(over Theme) Theme {
+	t.Name = overlayString(t.Name, over.Name)
+	t.Variant = overlayString(t.Variant, over.Variant)
+	t.Font = overlayString(t.Font, over.Font)
+	if over.FontSize != 0 {
+		t.FontSize = over.FontSize
+	}
+	t.Light = t.Light.overlay(over.Light)
+	t.Dark = t.Dark.overlay(over.Dark)
+	return t
+}
+
+// Validate checks the variant, font size and colors.
+func (t Theme) Validate() error {
+	if t.Variant != "" && t.Variant != ThemeVariantLight && t.Variant != ThemeVariantDark {
+		return fmt.Errorf("invalid theme variant %q, expected %s or %s", t.Variant, ThemeVariantLight, ThemeVariantDark)
+	}
+	if t.FontSize < 0 {
+		return fmt.Errorf("invalid theme font size %v", t.FontSize)
+	}
+	for _, colors := range []ThemeColors{t.Light, t.Dark} {
+		for _, value := range colors.values() {
+			if value == "" {
+				continue
+			}
+			if _, err := ParseColor(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c ThemeColors) overlay(over ThemeColors) ThemeColors {
+	return ThemeColors{
+		Background: overlayString(c.Background, over.Background),
+		Foreground: overlayString(c.Foreground, over.Foreground),
+		Selection:  overlayString(c.Selection, over.Selection),
+		Stripe:     overlayString(c.Stripe, over.Stripe),
+		Hover:      overlayString(c.Hover, over.Hover),
+		Match:      overlayString(c.Match, over.Match),
+	}
+}
+
+func (c ThemeColors) values() []string {
+	return []string{c.Background, c.Foreground, c.Selection, c.Stripe, c.Hover, c.Match}
+}
+
+func overlayString(base, over string) string {
+	if over != "" {
+		return over
+	}
+	return base
+}
+
+// ParseColor parses a #rgb, #rrggbb or #rrggbbaa hex color.
+func ParseColor(value string) (color.NRGBA, error) {
+	hex, ok := strings.CutPrefix(value, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if ok && len(hex) == 6 {
+		hex += "ff"
+	}
+	if !ok || len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #rgb, #rrggbb or #rrggbbaa", value)
+	}
+	rgba, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #rgb, #rrggbb or #rrggbbaa", value)
+	}
+	return color.NRGBA{R: uint8(rgba >> 24), G: uint8(rgba >> 16), B: uint8(rgba >> 8), A: uint8(rgba)}, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColor(t *testing.T) {
+	c, err := ParseColor("#3d9fff")
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0x3d, G: 0x9f, B: 0xff, A: 0xff}, c)
+
+	c, err = ParseColor("#3d9fff40")
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0x3d, G: 0x9f, B: 0xff, A: 0x40}, c)
+
+	c, err = ParseColor("#fa0")
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xaa, B: 0x00, A: 0xff}, c)
+
+	for _, value := range []string{"3d9fff", "#3d9f", "#gggggg", "blue", ""} {
+		_, err := ParseColor(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestThemeOverlay(t *testing.T) {
+	base := Theme{
+		Variant:  ThemeVariantDark,
+		FontSize: 14,
+		Dark:     ThemeColors{Background: "#000000", Match: "#ff0000"},
+	}
+	merged := base.Overlay(Theme{Name: "nord", FontSize: 18, Dark: ThemeColors{Match: "#00ff00"}})
+	assert.Equal(t, Theme{
+		Name:     "nord",
+		Variant:  ThemeVariantDark,
+		FontSize: 18,
+		Dark:     ThemeColors{Background: "#000000", Match: "#00ff00"},
+	}, merged)
+}
+
+func TestThemeValidate(t *testing.T) {
+	assert.NoError(t, Theme{}.Validate())
+	assert.NoError(t, Theme{Variant: ThemeVariantLight, Light: ThemeColors{Stripe: "#0000000c"}}.Validate())
+	assert.Error(t, Theme{Variant: "sepia"}.Validate())
+	assert.Error(t, Theme{FontSize: -1}.Validate())
+	assert.Error(t, Theme{Dark: ThemeColors{Hover: "blue"}}.Validate())
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/hamidzr/gmenu/model"
+	"github.com/hamidzr/gmenu/store"
 	"gopkg.in/yaml.v2"
 )
 
@@ -18,61 +19,89 @@ var comboSwitcherCanonicalKeys = map[string]string{
 	"menuid":                "menu_id",
 	"searchmethod":          "search_method",
 	"preserveorder":         "preserve_order",
+	"ignorediacritics":      "ignore_diacritics",
 	"initialquery":          "initial_query",
 	"autoaccept":            "auto_accept",
 	"terminalmode":          "terminal_mode",
 	"nonumericselection":    "no_numeric_selection",
+	"showscore":             "show_score",
 	"minwidth":              "min_width",
 	"minheight":             "min_height",
 	"maxwidth":              "max_width",
 	"maxheight":             "max_height",
+	"maxresults":            "max_results",
+	"inputformat":           "input_format",
+	"outputformat":          "output_format",
+	"delimiter":             "delimiter",
+	"withnth":               "with_nth",
+	"outputnth":             "output_nth",
+	"groupnth":              "group_nth",
+	"grouporder":            "group_order",
+	"headerlines":           "header_lines",
+	"message":               "message",
+	"preview":               "preview",
+	"previewposition":       "preview_position",
+	"multi":                 "multi",
+	"markkey":               "mark_key",
+	"keybindings":           "keybindings",
+	"theme":                 "theme",
+	"onconflict":            "on_conflict",
+	"statedir":              "state_dir",
 	"acceptcustomselection": "accept_custom_selection",
 }
 
-// getConfigPaths returns the config directory paths in priority order
-// prefers ~/.config over macos application support dir
-func GetConfigPaths(menuID string) []string {
-	var paths []string
-
-	// when menu ID is provided, prioritize namespaced configs
-	if menuID != "" {
-		if homeDir, err := os.UserHomeDir(); err == nil {
-			paths = append(paths, filepath.Join(homeDir, ".config", "gmenu", menuID))
-			paths = append(paths, filepath.Join(homeDir, ".gmenu", menuID))
+// configBaseDirs returns the gmenu config directories in priority order:
+// $XDG_CONFIG_HOME, then ~/.config, ~/.gmenu and the OS user config dir.
+func configBaseDirs() []string {
+	var dirs []string
+	add := func(dir string) {
+		if !filepath.IsAbs(dir) {
+			return // HOME isn't set
 		}
-		if configDir, err := os.UserConfigDir(); err == nil {
-			paths = append(paths, filepath.Join(configDir, "gmenu", menuID))
+		for _, existing := range dirs {
+			if existing == dir {
+				return
+			}
 		}
+		dirs = append(dirs, dir)
 	}
-
+	add(store.ConfigDir("gmenu"))
 	if homeDir, err := os.UserHomeDir(); err == nil {
-		paths = append(paths, filepath.Join(homeDir, ".config", "gmenu"))
-		paths = append(paths, filepath.Join(homeDir, ".gmenu"))
+		add(filepath.Join(homeDir, ".config", "gmenu"))
+		add(filepath.Join(homeDir, ".gmenu"))
 	}
 	if configDir, err := os.UserConfigDir(); err == nil {
-		paths = append(paths, filepath.Join(configDir, "gmenu"))
+		add(filepath.Join(configDir, "gmenu"))
 	}
-
-	return paths
+	return dirs
 }
 
-// getPreferredConfigDir returns the preferred config directory for writing
-func GetPreferredConfigDir(menuID string) (string, error) {
-	if homeDir, err := os.UserHomeDir(); err == nil {
-		if menuID != "" {
-			return filepath.Join(homeDir, ".config", "gmenu", menuID), nil
-		}
-		return filepath.Join(homeDir, ".config", "gmenu"), nil
-	}
+// GetConfigPaths returns the config directory paths in priority order.
+// Namespaced directories of the menu ID come before the shared ones.
+func GetConfigPaths(menuID string) []string {
+	bases := configBaseDirs()
+	var paths []string
 
-	if userConfigDir, err := os.UserConfigDir(); err == nil {
-		if menuID != "" {
-			return filepath.Join(userConfigDir, "gmenu", menuID), nil
+	// when menu ID is provided, prioritize namespaced configs
+	if menuID != "" {
+		for _, base := range bases {
+			paths = append(paths, filepath.Join(base, menuID))
 		}
-		return filepath.Join(userConfigDir, "gmenu"), nil
 	}
+	return append(paths, bases...)
+}
 
-	return "", fmt.Errorf("unable to determine config directory")
+// GetPreferredConfigDir returns the preferred config directory for writing,
+// under $XDG_CONFIG_HOME or ~/.config.
+func GetPreferredConfigDir(menuID string) (string, error) {
+	dir := store.ConfigDir("gmenu")
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("unable to determine config directory")
+	}
+	if menuID != "" {
+		return filepath.Join(dir, menuID), nil
+	}
+	return dir, nil
 }
 
 // GetConfigByMenuID loads the config for a given menu ID from the filesystem.
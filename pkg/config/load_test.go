@@ -62,3 +62,91 @@ func TestGetConfigByMenuID_DetectsDuplicateComboKeys(t *testing.T) {
 		t.Fatal("expected error due to duplicate key variants, got nil")
 	}
 }
+
+func TestLoadTheme(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, ".config"))
+
+	themeDir := filepath.Join(tempDir, ".config", "gmenu", "themes")
+	if err := os.MkdirAll(themeDir, 0o755); err != nil {
+		t.Fatalf("create theme dir: %v", err)
+	}
+	nord := "variant: dark\nfont_size: 18\ndark:\n  background: \"#2e3440\"\n"
+	if err := os.WriteFile(filepath.Join(themeDir, "nord.yaml"), []byte(nord), 0o644); err != nil {
+		t.Fatalf("write theme: %v", err)
+	}
+
+	theme, err := LoadTheme("nord", "")
+	if err != nil {
+		t.Fatalf("LoadTheme returned error: %v", err)
+	}
+	if theme.Variant != "dark" || theme.FontSize != 18 || theme.Dark.Background != "#2e3440" {
+		t.Fatalf("unexpected theme: %+v", theme)
+	}
+
+	// menus fall back to the shared themes directory
+	if _, err := LoadTheme("nord", "my-menu"); err != nil {
+		t.Fatalf("LoadTheme for menu returned error: %v", err)
+	}
+	// a path is loaded directly
+	if _, err := LoadTheme(filepath.Join(themeDir, "nord.yaml"), ""); err != nil {
+		t.Fatalf("LoadTheme by path returned error: %v", err)
+	}
+
+	if _, err := LoadTheme("missing", ""); err == nil {
+		t.Fatalf("expected an error for a missing theme")
+	}
+
+	bad := "dark:\n  backgrund: \"#000000\"\n"
+	if err := os.WriteFile(filepath.Join(themeDir, "bad.yaml"), []byte(bad), 0o644); err != nil {
+		t.Fatalf("write theme: %v", err)
+	}
+	if _, err := LoadTheme("bad", ""); err == nil || !strings.Contains(err.Error(), "backgrund") {
+		t.Fatalf("expected an unknown key error, got %v", err)
+	}
+}
+
+func TestGetConfigPathsXDG(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	paths := GetConfigPaths("files")
+	want := []string{
+		filepath.Join(xdg, "gmenu", "files"),
+		filepath.Join(home, ".config", "gmenu", "files"),
+		filepath.Join(home, ".gmenu", "files"),
+	}
+	if len(paths) < len(want) {
+		t.Fatalf("unexpected config paths: %v", paths)
+	}
+	for i, path := range want {
+		if paths[i] != path {
+			t.Fatalf("config path %d = %s, want %s", i, paths[i], path)
+		}
+	}
+
+	dir, err := GetPreferredConfigDir("files")
+	if err != nil {
+		t.Fatalf("GetPreferredConfigDir returned error: %v", err)
+	}
+	if dir != want[0] {
+		t.Fatalf("preferred config dir = %s, want %s", dir, want[0])
+	}
+
+	// no duplicates when XDG_CONFIG_HOME is ~/.config
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	paths = GetConfigPaths("")
+	if paths[0] != filepath.Join(home, ".config", "gmenu") {
+		t.Fatalf("unexpected first config path: %s", paths[0])
+	}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if seen[path] {
+			t.Fatalf("duplicate config path %s in %v", path, paths)
+		}
+		seen[path] = true
+	}
+}
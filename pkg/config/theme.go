@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hamidzr/gmenu/model"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// themesDir is the directory next to config.yaml that holds named themes.
+const themesDir = "themes"
+
+// ParseTheme parses a theme document, rejecting unknown keys.
+func ParseTheme(data []byte) (model.Theme, error) {
+	var theme model.Theme
+	decoder := yamlv3.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&theme); err != nil && !errors.Is(err, io.EOF) {
+		return model.Theme{}, fmt.Errorf("invalid theme: %w", err)
+	}
+	return theme, nil
+}
+
+// LoadTheme loads the named theme from themes/<name>.yaml in the first config
+// directory of menuID that has it. A name that is a path to a file is loaded
+// directly.
+func LoadTheme(name, menuID string) (model.Theme, error) {
+	paths := []string{name}
+	if !strings.ContainsRune(name, filepath.Separator) {
+		paths = paths[:0]
+		for _, dir := range GetConfigPaths(menuID) {
+			paths = append(paths, filepath.Join(dir, themesDir, name+".yaml"))
+		}
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return model.Theme{}, fmt.Errorf("failed to read theme %q: %w", name, err)
+		}
+		theme, err := ParseTheme(data)
+		if err != nil {
+			return model.Theme{}, fmt.Errorf("theme file %s: %w", path, err)
+		}
+		if theme.Name != "" {
+			return model.Theme{}, fmt.Errorf("theme file %s can't load another theme", path)
+		}
+		if err := theme.Validate(); err != nil {
+			return model.Theme{}, fmt.Errorf("theme file %s: %w", path, err)
+		}
+		return theme, nil
+	}
+	return model.Theme{}, fmt.Errorf("theme %q not found, looked for %s/%s.yaml in the config directories", name, themesDir, name)
+}
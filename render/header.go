@@ -0,0 +1,63 @@
+package render
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// HeaderArea shows a message and the fixed header lines of the input between
+// the search box and the list. Empty parts are hidden.
+type HeaderArea struct {
+	Container *fyne.Container
+	message   *widget.Label
+	headers   *widget.Label
+}
+
+// NewHeaderArea creates an empty, hidden header area.
+func NewHeaderArea() *HeaderArea {
+	message := widget.NewLabel("")
+	message.Wrapping = fyne.TextWrapWord
+	message.Importance = widget.LowImportance
+	message.Hide()
+	headers := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	headers.Truncation = fyne.TextTruncateEllipsis
+	headers.Hide()
+	return &HeaderArea{
+		Container: container.NewVBox(message, headers),
+		message:   message,
+		headers:   headers,
+	}
+}
+
+// SetMessage replaces the message shown under the search box.
+func (h *HeaderArea) SetMessage(text string) {
+	h.message.SetText(text)
+	setShown(h.message, text != "")
+}
+
+// SetHeaderLines replaces the header lines shown above the list.
+func (h *HeaderArea) SetHeaderLines(lines []string) {
+	h.headers.SetText(strings.Join(lines, "\n"))
+	setShown(h.headers, len(lines) > 0)
+}
+
+// Message returns the current message.
+func (h *HeaderArea) Message() string {
+	return h.message.Text
+}
+
+// HeaderLines returns the current header lines joined by newlines.
+func (h *HeaderArea) HeaderLines() string {
+	return h.headers.Text
+}
+
+func setShown(object fyne.CanvasObject, shown bool) {
+	if shown {
+		object.Show()
+	} else {
+		object.Hide()
+	}
+}
@@ -10,9 +10,12 @@ import (
 // SearchEntry is a widget.Entry that captures certain key events.
 type SearchEntry struct {
 	widget.Entry
-	OnKeyDown            func(key *fyne.KeyEvent)
+	OnKeyDown func(key *fyne.KeyEvent)
+	// OnShortcut handles modifier key chords. Returning true consumes the shortcut.
+	OnShortcut           func(shortcut fyne.KeyboardShortcut) bool
 	PropagationBlacklist map[fyne.KeyName]bool
 	OnFocusLost          func()
+	shiftPressed         bool
 }
 
 // SelectAll selects all text in the entry.
@@ -39,16 +42,34 @@ func (e *SearchEntry) TypedKey(key *fyne.KeyEvent) {
 	e.Entry.TypedKey(key)
 }
 
+// KeyDown implements the desktop.Keyable interface and tracks shift state,
+// since plain key events don't carry modifiers.
+func (e *SearchEntry) KeyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shiftPressed = true
+	}
+	e.Entry.KeyDown(key)
+}
+
+// KeyUp implements the desktop.Keyable interface.
+func (e *SearchEntry) KeyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shiftPressed = false
+	}
+	e.Entry.KeyUp(key)
+}
+
+// ShiftPressed reports whether a shift key is currently held down.
+func (e *SearchEntry) ShiftPressed() bool {
+	return e.shiftPressed
+}
+
 // TypedShortcut implements the fyne.TypedShortcutReceiver interface.
 func (e *SearchEntry) TypedShortcut(shortcut fyne.Shortcut) {
-	s, ok := shortcut.(*desktop.CustomShortcut)
-	if !ok {
-		e.Entry.TypedShortcut(shortcut)
+	if s, ok := shortcut.(fyne.KeyboardShortcut); ok && e.OnShortcut != nil && e.OnShortcut(s) {
 		return
 	}
-	if s.Mod() == fyne.KeyModifierControl && s.Key() == fyne.KeyL {
-		e.SetText("")
-	}
+	e.Entry.TypedShortcut(shortcut)
 }
 
 // FocusLost implements the fyne.Focusable interface.
@@ -3,6 +3,7 @@ package render
 import (
 	"fmt"
 	"image/color"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -17,166 +18,313 @@ import (
 render a list of items
 */
 
-// ItemsCanvas is a container for showing a list of items.
+// ItemsCanvas shows a scrollable list of items, with a header before every
+// group of grouped items. Rows are rendered lazily by a widget.List so only
+// the visible items are built, and a row's widgets are reused for whatever
+// item scrolls into it.
 type ItemsCanvas struct {
 	Container *fyne.Container
-	// LengthLimit int
+	List      *widget.List
+	// IsMarked reports whether an item is marked in multi-select mode.
+	IsMarked func(item model.MenuItem) bool
+	// ShowScore shows the search score of every item.
+	ShowScore bool
+
+	mu    sync.Mutex
+	items []model.MenuItem
+	// rows lays out items with group headers, nil when items are ungrouped.
+	rows               []model.ListRow
+	selected           int
+	noNumericSelection bool
+	onItemClick        func(int)
+	// views maps the rows handed to the list to their widgets.
+	views map[fyne.CanvasObject]*itemRow
 }
 
-// NewItemsCanvas initializes ItemsCanvas with a container.
+// NewItemsCanvas initializes ItemsCanvas with an empty list.
 func NewItemsCanvas() *ItemsCanvas {
-	// Create an empty container for items
-	cont := container.NewVBox()
-	return &ItemsCanvas{
-		Container: cont,
-		// LengthLimit: 999,
+	c := &ItemsCanvas{views: make(map[fyne.CanvasObject]*itemRow)}
+	c.List = widget.NewList(c.length, c.createRow, c.updateRow)
+	c.Container = container.NewStack(c.List)
+	return c
+}
+
+func (c *ItemsCanvas) length() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rows != nil {
+		return len(c.rows)
 	}
+	return len(c.items)
 }
 
-func RenderItem(item model.MenuItem, idx int, selected bool, noNumericSelection bool, onItemClick func(int)) *fyne.Container {
-	// Safety check for item
-	title := item.ComputedTitle()
-	if title == "" {
-		title = "Empty Item" // Fallback for empty items
+// createRow returns a row for the list to fill in. Its min size sets the row
+// height.
+func (c *ItemsCanvas) createRow() fyne.CanvasObject {
+	row := newTemplateRow()
+	c.mu.Lock()
+	c.views[row.view] = row
+	c.mu.Unlock()
+	return row.view
+}
+
+func (c *ItemsCanvas) updateRow(id widget.ListItemID, object fyne.CanvasObject) {
+	c.mu.Lock()
+	row := c.views[object]
+	if row == nil {
+		c.mu.Unlock()
+		return
 	}
+	idx := id
+	if c.rows != nil {
+		if id < 0 || id >= len(c.rows) {
+			c.mu.Unlock()
+			return
+		}
+		if c.rows[id].IsHeader() {
+			header := c.rows[id].Header
+			c.mu.Unlock()
+			row.setHeader(header)
+			return
+		}
+		idx = c.rows[id].Item
+	}
+	if idx < 0 || idx >= len(c.items) {
+		c.mu.Unlock()
+		return
+	}
+	item := c.items[idx]
+	selected := idx == c.selected
+	noNumericSelection := c.noNumericSelection
+	onItemClick := c.onItemClick
+	c.mu.Unlock()
 
-	// create the main text content
-	optionText := widget.NewLabel(title)
-	optionText.Truncation = fyne.TextTruncateEllipsis
-	if selected {
-		optionText.TextStyle = fyne.TextStyle{Bold: true}
+	marked := c.IsMarked != nil && c.IsMarked(item)
+	row.setItem(item, idx, selected, marked, noNumericSelection, c.ShowScore, onItemClick)
+}
+
+// RenderGroupHeader renders the header row of a group. Headers can't be
+// selected or clicked.
+func RenderGroupHeader(name string) *fyne.Container {
+	header, label := newGroupHeader()
+	label.SetText(name)
+	return header
+}
+
+func newGroupHeader() (*fyne.Container, *widget.Label) {
+	label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	label.Importance = widget.LowImportance
+	label.Truncation = fyne.TextTruncateEllipsis
+	separator := canvas.NewLine(theme.Color(theme.ColorNameSeparator))
+	separator.StrokeWidth = 1
+	return container.NewBorder(nil, separator, nil, nil, label), label
+}
+
+// PageSize returns how many rows fit in the visible part of the list.
+func (c *ItemsCanvas) PageSize() int {
+	const fallbackPageSize = 10
+	rowHeight := newTemplateRow().view.MinSize().Height + theme.Padding()
+	if c.List == nil || c.List.Size().Height <= 0 || rowHeight <= 0 {
+		return fallbackPageSize
 	}
+	return max(int(c.List.Size().Height/rowHeight), 1)
+}
 
-	// add icon if present
-	var iconWidget *widget.Icon
-	if item.Icon != "" {
-		// simple icon mapping based on common patterns
-		switch item.Icon {
-		case "app", "application":
-			iconWidget = widget.NewIcon(theme.ComputerIcon())
-		case "file":
-			iconWidget = widget.NewIcon(theme.DocumentIcon())
-		case "folder", "directory":
-			iconWidget = widget.NewIcon(theme.FolderIcon())
-		default:
-			iconWidget = widget.NewIcon(theme.InfoIcon())
-		}
-		iconWidget.Resize(fyne.NewSize(16, 16))
+// RenderItem renders a single row. The search score is only shown with
+// showScore since nearly every match has one.
+func RenderItem(item model.MenuItem, idx int, selected bool, marked bool, noNumericSelection bool, showScore bool, onItemClick func(int)) *fyne.Container {
+	row := newItemRow()
+	row.setItem(item, idx, selected, marked, noNumericSelection, showScore, onItemClick)
+	return row.view
+}
+
+// itemRow is a list row. Its widgets are built once and updated in place to
+// show an item or a group header.
+type itemRow struct {
+	view       *fyne.Container
+	item       *fyne.Container
+	background *canvas.Rectangle
+	mark       *widget.Icon
+	number     *widget.Label
+	icon       *widget.Icon
+	title      *widget.RichText
+	score      *widget.Label
+	tapArea    *hoverableArea
+	header     *fyne.Container
+	headerText *widget.Label
+
+	// the shown item, read by the tap and hover handlers
+	idx         int
+	selected    bool
+	baseFill    color.Color
+	onItemClick func(int)
+}
+
+func newItemRow() *itemRow {
+	r := &itemRow{}
+	r.title = widget.NewRichText()
+	r.title.Truncation = fyne.TextTruncateEllipsis
+	// a check mark in front of items marked in multi-select mode
+	r.mark = widget.NewIcon(theme.CheckButtonCheckedIcon())
+	r.number = widget.NewLabel("")
+	r.number.TextStyle = fyne.TextStyle{Italic: true}
+	r.icon = widget.NewIcon(nil)
+	textContent := container.NewBorder(nil, nil, container.NewHBox(r.mark, r.number, r.icon), nil, r.title)
+
+	r.score = widget.NewLabel("")
+	r.score.Alignment = fyne.TextAlignTrailing
+	r.score.TextStyle = fyne.TextStyle{Italic: true}
+
+	// light padding for comfortable spacing
+	padded := func(object fyne.CanvasObject) *fyne.Container {
+		return container.New(layout.NewCustomPaddedLayout(2, 2, 4, 4), object)
 	}
+	r.background = canvas.NewRectangle(color.Transparent)
+	r.tapArea = newHoverableArea(r.tapped, r.hovered)
+	r.item = container.NewStack(r.background, container.NewBorder(nil, nil, nil, padded(r.score), padded(textContent)), r.tapArea)
 
-	var textContent *fyne.Container
-	if !noNumericSelection && idx < 9 {
-		// create number hint on the left with fixed width to prevent overlap
-		numberHint := widget.NewLabel(fmt.Sprintf("%d", idx+1))
-		numberHint.Alignment = fyne.TextAlignLeading
-		numberHint.TextStyle = fyne.TextStyle{Bold: false, Italic: true}
-		numberHint.Importance = widget.MediumImportance
-
-		// create a container for the number with fixed width
-		numberContainer := container.NewStack(numberHint)
-		numberContainer.Resize(fyne.NewSize(20, numberContainer.MinSize().Height)) // fixed width for numbers
-
-		// add some spacing between number and text
-		spacer := layout.NewSpacer()
-		spacer.Resize(fyne.NewSize(4, 1)) // small spacer
-
-		// combine number, icon (if present), and text
-		leftContent := container.NewHBox(numberContainer, spacer)
-		if iconWidget != nil {
-			leftContent.Add(iconWidget)
-			iconSpacer := layout.NewSpacer()
-			iconSpacer.Resize(fyne.NewSize(4, 1))
-			leftContent.Add(iconSpacer)
-		}
+	r.header, r.headerText = newGroupHeader()
+	r.view = container.NewStack(r.item, r.header)
+	return r
+}
 
-		textContent = container.NewBorder(nil, nil, leftContent, nil, optionText)
-	} else {
-		// just icon and text without numbers
-		if iconWidget != nil {
-			iconSpacer := layout.NewSpacer()
-			iconSpacer.Resize(fyne.NewSize(4, 1))
-			textContent = container.NewBorder(nil, nil, container.NewHBox(iconWidget, iconSpacer), nil, optionText)
-		} else {
-			textContent = container.NewStack(optionText)
-		}
+// newTemplateRow returns a row sized like an item row.
+func newTemplateRow() *itemRow {
+	row := newItemRow()
+	row.setItem(model.MenuItem{Title: "template"}, 0, false, false, true, false, nil)
+	return row
+}
+
+// setHeader shows the header of group name in the row.
+func (r *itemRow) setHeader(name string) {
+	r.onItemClick = nil
+	r.headerText.SetText(name)
+	r.item.Hide()
+	r.header.Show()
+}
+
+// setItem shows item in the row.
+func (r *itemRow) setItem(item model.MenuItem, idx int, selected bool, marked bool, noNumericSelection bool, showScore bool, onItemClick func(int)) {
+	r.header.Hide()
+	r.idx, r.selected, r.onItemClick = idx, selected, onItemClick
+
+	title := item.ComputedTitle()
+	if title == "" {
+		title = "Empty Item" // Fallback for empty items
 	}
+	// matched characters are highlighted
+	r.title.Segments = titleSegments(title, item.Matches, selected)
+	r.title.Refresh()
 
-	// create score metadata if needed
-	var metadata *widget.Label
-	if item.Score != 0 {
-		metadata = widget.NewLabel(fmt.Sprintf("%d", item.Score))
-		metadata.Alignment = fyne.TextAlignTrailing
-		metadata.TextStyle = fyne.TextStyle{Bold: false, Italic: true}
+	setShown(r.mark, marked)
+	if !noNumericSelection && idx < 9 {
+		r.number.SetText(fmt.Sprintf("%d", idx+1))
+		r.number.Show()
+	} else {
+		r.number.Hide()
+	}
+	if item.Icon != "" {
+		r.icon.SetResource(itemIcon(item.Icon))
+		r.icon.Show()
+	} else {
+		r.icon.Hide()
+	}
+	if showScore && item.Score != 0 {
+		r.score.SetText(fmt.Sprintf("%d", item.Score))
+		r.score.Show()
+	} else {
+		r.score.SetText("")
+		r.score.Hide()
 	}
 
-	// create background with better styling and separation
-	background := canvas.NewRectangle(color.Transparent)
 	if selected {
-		background.FillColor = theme.Color(theme.ColorNameSelection)
+		r.background.FillColor = theme.Color(theme.ColorNameSelection)
+		r.background.StrokeWidth = 0
 	} else {
 		// subtle alternating row colors for better item separation
 		if idx%2 == 0 {
-			background.FillColor = color.NRGBA{R: 0, G: 0, B: 0, A: 12} // slightly more visible stripe
+			r.background.FillColor = theme.Color(ColorNameStripe)
 		} else {
-			background.FillColor = color.Transparent
+			r.background.FillColor = color.Transparent
 		}
 		// add subtle bottom border for item separation
-		background.StrokeColor = color.NRGBA{R: 128, G: 128, B: 128, A: 30}
-		background.StrokeWidth = 0.5
+		r.background.StrokeColor = color.NRGBA{R: 128, G: 128, B: 128, A: 30}
+		r.background.StrokeWidth = 0.5
 	}
-	baseFill := background.FillColor
+	r.baseFill = r.background.FillColor
+	r.background.Refresh()
 
-	// add light padding for comfortable spacing
-	paddedContent := container.NewWithoutLayout(textContent)
-	paddedContent.Move(fyne.NewPos(4, 2))
+	// Make the item clickable if callback is provided
+	setShown(r.tapArea, onItemClick != nil)
+	r.item.Show()
+}
 
-	// compose final container with balanced padding
-	var itemContainer *fyne.Container
-	if metadata != nil {
-		paddedMetadata := container.NewWithoutLayout(metadata)
-		paddedMetadata.Move(fyne.NewPos(-4, 2))
-		itemContainer = container.NewStack(background, container.NewBorder(nil, nil, nil, paddedMetadata, paddedContent))
-	} else {
-		itemContainer = container.NewStack(background, paddedContent)
+func (r *itemRow) tapped() {
+	if r.onItemClick != nil {
+		r.onItemClick(r.idx)
 	}
+}
 
-	itemContainer.Layout = layout.NewStackLayout()
-
-	// Make the item clickable if callback is provided
-	if onItemClick != nil {
-		hoverColor := color.NRGBA{R: 0x3d, G: 0x9f, B: 0xff, A: 0x40}
-		tapArea := newHoverableArea(func() {
-			onItemClick(idx)
-		}, func(hovered bool) {
-			if selected {
-				return
-			}
-			if hovered {
-				background.FillColor = hoverColor
-			} else {
-				background.FillColor = baseFill
-			}
-			background.Refresh()
-		})
-		return container.NewStack(itemContainer, tapArea)
-	}
-
-	return itemContainer
-}
-
-// Render updates the container with items, highlighting the selected one.
-func (c *ItemsCanvas) Render(items []model.MenuItem, selected int, noNumericSelection bool, onItemClick func(int)) {
-	// Safety checks to prevent nil pointer dereferences
-	if c == nil || c.Container == nil {
+func (r *itemRow) hovered(hovered bool) {
+	if r.selected {
 		return
 	}
+	if hovered {
+		r.background.FillColor = theme.Color(ColorNameHover)
+	} else {
+		r.background.FillColor = r.baseFill
+	}
+	r.background.Refresh()
+}
 
-	c.Container.Objects = nil // Clear current items
+// itemIcon maps the icon name of an item to a theme icon.
+func itemIcon(name string) fyne.Resource {
+	switch name {
+	case "app", "application":
+		return theme.ComputerIcon()
+	case "file":
+		return theme.DocumentIcon()
+	case "folder", "directory":
+		return theme.FolderIcon()
+	default:
+		return theme.InfoIcon()
+	}
+}
 
-	// Handle empty items array
-	if len(items) == 0 {
-		c.Container.Add(layout.NewSpacer()) // Add a final spacer for consistent look
-		c.Container.Refresh()               // Refresh the container to apply changes
+// titleSegments splits a title into rich text segments, highlighting the
+// matched ranges.
+func titleSegments(title string, matches []model.MatchRange, selected bool) []widget.RichTextSegment {
+	plain := widget.RichTextStyleInline
+	plain.TextStyle = fyne.TextStyle{Bold: selected}
+	highlight := widget.RichTextStyleInline
+	highlight.ColorName = ColorNameMatch
+	highlight.TextStyle = fyne.TextStyle{Bold: true}
+
+	runes := []rune(title)
+	segments := make([]widget.RichTextSegment, 0, 2*len(matches)+1)
+	pos := 0
+	for _, r := range matches {
+		start, end := max(r.Start, pos), min(r.End, len(runes))
+		if start >= end {
+			continue
+		}
+		if start > pos {
+			segments = append(segments, &widget.TextSegment{Text: string(runes[pos:start]), Style: plain})
+		}
+		segments = append(segments, &widget.TextSegment{Text: string(runes[start:end]), Style: highlight})
+		pos = end
+	}
+	if pos < len(runes) || len(segments) == 0 {
+		segments = append(segments, &widget.TextSegment{Text: string(runes[pos:]), Style: plain})
+	}
+	return segments
+}
+
+// Render updates the list with items, highlighting the selected one and
+// scrolling it into view.
+func (c *ItemsCanvas) Render(items []model.MenuItem, selected int, noNumericSelection bool, onItemClick func(int)) {
+	// Safety checks to prevent nil pointer dereferences
+	if c == nil || c.List == nil {
 		return
 	}
 
@@ -185,14 +333,22 @@ func (c *ItemsCanvas) Render(items []model.MenuItem, selected int, noNumericSele
 		selected = 0
 	}
 
-	for i, item := range items {
-		// Safety check for item title
-		if item.ComputedTitle() == "" {
-			continue // Skip empty items
+	rows := model.GroupRows(items)
+	c.mu.Lock()
+	c.items = items
+	c.rows = rows
+	c.selected = selected
+	c.noNumericSelection = noNumericSelection
+	c.onItemClick = onItemClick
+	c.mu.Unlock()
+
+	c.List.Refresh()
+	if len(items) > 0 {
+		row := model.ItemRow(rows, selected)
+		// bring the header of the group into view with its first item
+		if rows != nil && row > 0 && rows[row-1].IsHeader() {
+			c.List.ScrollTo(row - 1)
 		}
-		c.Container.Add(RenderItem(item, i, i == selected, noNumericSelection, onItemClick))
+		c.List.ScrollTo(row)
 	}
-
-	c.Container.Add(layout.NewSpacer()) // Add a final spacer for consistent look
-	c.Container.Refresh()               // Refresh the container to apply changes
 }
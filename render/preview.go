@@ -0,0 +1,38 @@
+package render
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// PreviewPane shows the output of the preview command in a scrollable,
+// monospaced text area.
+type PreviewPane struct {
+	Container *container.Scroll
+	text      *widget.TextGrid
+}
+
+// NewPreviewPane creates an empty preview pane.
+func NewPreviewPane() *PreviewPane {
+	text := widget.NewTextGrid()
+	return &PreviewPane{
+		Container: container.NewScroll(text),
+		text:      text,
+	}
+}
+
+// SetText replaces the preview content and scrolls back to the top.
+// Trailing newlines are dropped so they don't show up as empty rows.
+func (p *PreviewPane) SetText(text string) {
+	p.text.SetText(strings.TrimRight(text, "\n"))
+	p.Container.Offset = fyne.NewPos(0, 0)
+	p.Container.Refresh()
+}
+
+// Text returns the current preview content.
+func (p *PreviewPane) Text() string {
+	return p.text.Text()
+}
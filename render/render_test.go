@@ -1,10 +1,14 @@
 package render
 
 import (
+	"fmt"
+	"image/color"
 	"testing"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
 	"github.com/hamidzr/gmenu/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,9 +91,10 @@ func TestItemsCanvasCreation(t *testing.T) {
 
 	require.NotNil(t, canvas)
 	require.NotNil(t, canvas.Container)
+	require.NotNil(t, canvas.List)
 
 	// Initially should be empty
-	assert.Equal(t, 0, len(canvas.Container.Objects))
+	assert.Equal(t, 0, canvas.List.Length())
 }
 
 // TestRenderItem tests individual item rendering
@@ -135,7 +140,7 @@ func TestRenderItem(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			container := RenderItem(tc.item, tc.idx, tc.selected, tc.noNumericSelection, nil)
+			container := RenderItem(tc.item, tc.idx, tc.selected, false, tc.noNumericSelection, false, nil)
 
 			require.NotNil(t, container)
 			assert.Greater(t, len(container.Objects), 0, "Container should have at least one object")
@@ -147,6 +152,31 @@ func TestRenderItem(t *testing.T) {
 	}
 }
 
+// TestRenderItemScore tests that the score label is only shown on request
+func TestRenderItemScore(t *testing.T) {
+	test.NewApp()
+	item := model.MenuItem{Title: "scored", Score: 42}
+	hasScore := func(obj fyne.CanvasObject) bool {
+		found := false
+		var walk func(fyne.CanvasObject)
+		walk = func(obj fyne.CanvasObject) {
+			switch o := obj.(type) {
+			case *widget.Label:
+				found = found || o.Text == "42"
+			case *fyne.Container:
+				for _, child := range o.Objects {
+					walk(child)
+				}
+			}
+		}
+		walk(obj)
+		return found
+	}
+
+	assert.False(t, hasScore(RenderItem(item, 0, false, false, true, false, nil)))
+	assert.True(t, hasScore(RenderItem(item, 0, false, false, true, true, nil)))
+}
+
 // TestItemsCanvasWithItems tests adding items to the canvas
 func TestItemsCanvasWithItems(t *testing.T) {
 	canvas := NewItemsCanvas()
@@ -158,13 +188,58 @@ func TestItemsCanvasWithItems(t *testing.T) {
 		{Title: "item3"},
 	}
 
-	// Render items and add to canvas
-	for i, item := range items {
-		itemContainer := RenderItem(item, i, i == 0, false, nil) // first item selected
-		canvas.Container.Add(itemContainer)
+	canvas.Render(items, 0, false, nil) // first item selected
+
+	assert.Equal(t, len(items), canvas.List.Length())
+
+	// rendering fewer items shrinks the list
+	canvas.Render(items[:1], 0, false, nil)
+	assert.Equal(t, 1, canvas.List.Length())
+}
+
+// TestItemsCanvasGroupHeaders tests that grouped items get header rows
+func TestItemsCanvasGroupHeaders(t *testing.T) {
+	canvas := NewItemsCanvas()
+	items := []model.MenuItem{
+		{Title: "main.go", Group: "alacritty"},
+		{Title: "today.md", Group: "alacritty"},
+		{Title: "Spotify Premium", Group: "spotify"},
 	}
 
-	assert.Equal(t, len(items), len(canvas.Container.Objects))
+	canvas.Render(items, 2, false, nil)
+	assert.Equal(t, len(items)+2, canvas.List.Length())
+
+	// header rows show the group name, item rows keep their item index
+	object := canvas.createRow()
+	row := canvas.views[object]
+	canvas.updateRow(3, object)
+	assert.Equal(t, "spotify", row.headerText.Text)
+	assert.False(t, row.item.Visible())
+	assert.Equal(t, 4, model.ItemRow(canvas.rows, 2))
+
+	// the same row shows an item again without being rebuilt
+	title := row.title
+	canvas.updateRow(4, object)
+	assert.Same(t, title, row.title)
+	assert.True(t, row.item.Visible())
+	assert.False(t, row.header.Visible())
+	assert.Equal(t, "Spotify Premium", row.title.String())
+}
+
+func TestHeaderArea(t *testing.T) {
+	test.NewApp()
+	header := NewHeaderArea()
+	assert.False(t, header.message.Visible(), "an empty header area is hidden")
+	assert.False(t, header.headers.Visible())
+
+	header.SetMessage("pick one")
+	header.SetHeaderLines([]string{"PID CMD", "--- ---"})
+	assert.True(t, header.message.Visible())
+	assert.Equal(t, "pick one", header.Message())
+	assert.Equal(t, "PID CMD\n--- ---", header.HeaderLines())
+
+	header.SetHeaderLines(nil)
+	assert.False(t, header.headers.Visible())
 }
 
 // TestSearchEntryFocusLoss tests focus loss callback
@@ -211,7 +286,7 @@ func TestItemRenderingWithComplexContent(t *testing.T) {
 
 	for i, item := range testItems {
 		t.Run(item.Title, func(t *testing.T) {
-			container := RenderItem(item, i, false, false, nil)
+			container := RenderItem(item, i, false, false, false, false, nil)
 			require.NotNil(t, container)
 			assert.Greater(t, len(container.Objects), 0)
 		})
@@ -230,16 +305,89 @@ func TestSearchEntryTabHandling(t *testing.T) {
 func TestItemsCanvasLayout(t *testing.T) {
 	canvas := NewItemsCanvas()
 
-	// The container should use VBox layout for vertical item stacking
+	// The container stacks the list so it fills the available space
 	require.NotNil(t, canvas.Container.Layout)
+	require.Len(t, canvas.Container.Objects, 1)
+	assert.Equal(t, canvas.List, canvas.Container.Objects[0])
+}
 
-	// Add some items to test layout behavior
-	for i := 0; i < 3; i++ {
-		item := model.MenuItem{Title: "Item " + string(rune('1'+i))}
-		itemContainer := RenderItem(item, i, false, false, nil)
-		canvas.Container.Add(itemContainer)
+// TestItemsCanvasLargeList tests that every item is reachable without
+// building a widget per item
+func TestItemsCanvasLargeList(t *testing.T) {
+	app := test.NewApp()
+	defer app.Quit()
+
+	canvas := NewItemsCanvas()
+	window := app.NewWindow("items")
+	window.SetContent(canvas.Container)
+	window.Resize(fyne.NewSize(400, 300))
+
+	items := make([]model.MenuItem, 50000)
+	for i := range items {
+		items[i] = model.MenuItem{Title: fmt.Sprintf("file-%d", i)}
+	}
+	canvas.Render(items, len(items)-1, true, nil)
+
+	assert.Equal(t, len(items), canvas.List.Length())
+	pageSize := canvas.PageSize()
+	assert.Greater(t, pageSize, 0)
+	assert.Less(t, pageSize, 50)
+}
+
+// TestTitleSegments tests that matched ranges become highlighted segments
+func TestTitleSegments(t *testing.T) {
+	segmentTexts := func(segments []widget.RichTextSegment) ([]string, []bool) {
+		texts := make([]string, len(segments))
+		highlighted := make([]bool, len(segments))
+		for i, segment := range segments {
+			text := segment.(*widget.TextSegment)
+			texts[i] = text.Text
+			highlighted[i] = text.Style.ColorName == ColorNameMatch
+		}
+		return texts, highlighted
 	}
 
-	// After adding items, layout should still be valid
-	assert.Equal(t, 3, len(canvas.Container.Objects))
+	texts, highlighted := segmentTexts(titleSegments("naïve café", []model.MatchRange{{Start: 0, End: 2}, {Start: 6, End: 10}}, false))
+	assert.Equal(t, []string{"na", "ïve ", "café"}, texts)
+	assert.Equal(t, []bool{true, false, true}, highlighted)
+
+	texts, highlighted = segmentTexts(titleSegments("plain", nil, true))
+	assert.Equal(t, []string{"plain"}, texts)
+	assert.Equal(t, []bool{false}, highlighted)
+
+	// out of range matches are clamped
+	texts, _ = segmentTexts(titleSegments("abc", []model.MatchRange{{Start: 2, End: 9}}, false))
+	assert.Equal(t, []string{"ab", "c"}, texts)
+}
+
+// TestMainTheme tests that configured colors, variant and font size apply
+func TestMainTheme(t *testing.T) {
+	defaults, err := NewMainTheme(model.Theme{})
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0x3d, G: 0x9f, B: 0xff, A: 0x40}, defaults.Color(ColorNameHover, theme.VariantLight))
+	assert.Equal(t, theme.DefaultTheme().Color(theme.ColorNamePrimary, theme.VariantDark), defaults.Color(ColorNameMatch, theme.VariantDark))
+	assert.Equal(t, float32(16), defaults.Size(theme.SizeNameText))
+
+	custom, err := NewMainTheme(model.Theme{
+		FontSize: 20,
+		Light:    model.ThemeColors{Background: "#ffffff", Stripe: "#00000010"},
+		Dark:     model.ThemeColors{Background: "#2e3440", Match: "#88c0d0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, custom.Color(theme.ColorNameBackground, theme.VariantLight))
+	assert.Equal(t, color.NRGBA{R: 0x2e, G: 0x34, B: 0x40, A: 0xff}, custom.Color(theme.ColorNameBackground, theme.VariantDark))
+	assert.Equal(t, color.NRGBA{R: 0x88, G: 0xc0, B: 0xd0, A: 0xff}, custom.Color(ColorNameMatch, theme.VariantDark))
+	assert.Equal(t, color.NRGBA{R: 0, G: 0, B: 0, A: 0x10}, custom.Color(ColorNameStripe, theme.VariantLight))
+	assert.Equal(t, float32(20), custom.Size(theme.SizeNameText))
+	assert.Equal(t, float32(14*20)/16, custom.Size(theme.SizeNameCaptionText))
+	assert.Equal(t, float32(4), custom.Size(theme.SizeNamePadding))
+
+	forced, err := NewMainTheme(model.Theme{Variant: model.ThemeVariantDark, Dark: model.ThemeColors{Background: "#000000"}})
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{A: 0xff}, forced.Color(theme.ColorNameBackground, theme.VariantLight))
+
+	_, err = NewMainTheme(model.Theme{Font: "/nonexistent/font.ttf"})
+	assert.Error(t, err)
+	_, err = NewMainTheme(model.Theme{Dark: model.ThemeColors{Hover: "blue"}})
+	assert.Error(t, err)
 }
@@ -1,14 +1,81 @@
 package render
 
 import (
+	"fmt"
 	"image/color"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
+	"github.com/hamidzr/gmenu/model"
 )
 
+// Colors of the parts of the list that gmenu draws itself.
+const (
+	ColorNameStripe fyne.ThemeColorName = "gmenuStripe"
+	ColorNameHover  fyne.ThemeColorName = "gmenuHover"
+	ColorNameMatch  fyne.ThemeColorName = "gmenuMatch"
+)
+
+// MainTheme is the gmenu look on top of the Fyne default theme, with the
+// colors and font of the configured theme.
 type MainTheme struct {
 	fyne.Theme
+	// colors holds the configured colors of each variant.
+	colors map[fyne.ThemeVariant]map[fyne.ThemeColorName]color.Color
+	// variant is used instead of the system variant when forceVariant is set.
+	variant      fyne.ThemeVariant
+	forceVariant bool
+	font         fyne.Resource
+	// textScale scales the text sizes to the configured font size.
+	textScale float32
+}
+
+// NewMainTheme builds the theme for conf on top of the default theme.
+func NewMainTheme(conf model.Theme) (MainTheme, error) {
+	if err := conf.Validate(); err != nil {
+		return MainTheme{}, err
+	}
+	t := MainTheme{
+		Theme: theme.DefaultTheme(),
+		colors: map[fyne.ThemeVariant]map[fyne.ThemeColorName]color.Color{
+			theme.VariantLight: {},
+			theme.VariantDark:  {},
+		},
+		textScale: 1,
+	}
+	switch conf.Variant {
+	case model.ThemeVariantLight:
+		t.variant, t.forceVariant = theme.VariantLight, true
+	case model.ThemeVariantDark:
+		t.variant, t.forceVariant = theme.VariantDark, true
+	}
+	for variant, colors := range map[fyne.ThemeVariant]model.ThemeColors{theme.VariantLight: conf.Light, theme.VariantDark: conf.Dark} {
+		for name, value := range map[fyne.ThemeColorName]string{
+			theme.ColorNameBackground: colors.Background,
+			theme.ColorNameForeground: colors.Foreground,
+			theme.ColorNameSelection:  colors.Selection,
+			ColorNameStripe:           colors.Stripe,
+			ColorNameHover:            colors.Hover,
+			ColorNameMatch:            colors.Match,
+		} {
+			if value == "" {
+				continue
+			}
+			// colors were validated above
+			t.colors[variant][name], _ = model.ParseColor(value)
+		}
+	}
+	if conf.Font != "" {
+		font, err := fyne.LoadResourceFromPath(conf.Font)
+		if err != nil {
+			return MainTheme{}, fmt.Errorf("failed to load theme font: %w", err)
+		}
+		t.font = font
+	}
+	if conf.FontSize > 0 {
+		t.textScale = conf.FontSize / defaultThemeSizes()[theme.SizeNameText]
+	}
+	return t, nil
 }
 
 func defaultThemeSizes() map[fyne.ThemeSizeName]float32 {
@@ -31,13 +98,47 @@ func defaultThemeSizes() map[fyne.ThemeSizeName]float32 {
 
 func (m MainTheme) Size(name fyne.ThemeSizeName) float32 {
 	switch name {
+	case theme.SizeNameText, theme.SizeNameHeadingText, theme.SizeNameSubHeadingText, theme.SizeNameCaptionText:
+		return defaultThemeSizes()[name] * m.scale()
 	default:
 		return defaultThemeSizes()[name]
 	}
 }
 
+func (m MainTheme) scale() float32 {
+	if m.textScale == 0 {
+		return 1
+	}
+	return m.textScale
+}
+
+// Font returns the configured font for regular text.
+func (m MainTheme) Font(style fyne.TextStyle) fyne.Resource {
+	if m.font != nil && !style.Monospace && !style.Symbol {
+		return m.font
+	}
+	return m.Theme.Font(style)
+}
+
 func (m MainTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if m.forceVariant {
+		variant = m.variant
+	}
+	if c, ok := m.colors[variant][name]; ok {
+		return c
+	}
+	return defaultColor(name, variant)
+}
+
+// defaultColor returns the gmenu color for name when the theme doesn't set it.
+func defaultColor(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
 	switch name {
+	case ColorNameStripe:
+		return color.NRGBA{R: 0, G: 0, B: 0, A: 12} // slightly more visible stripe
+	case ColorNameHover:
+		return color.NRGBA{R: 0x3d, G: 0x9f, B: 0xff, A: 0x40}
+	case ColorNameMatch:
+		return theme.DefaultTheme().Color(theme.ColorNamePrimary, variant)
 	case theme.ColorNameSelection:
 		return color.NRGBA{R: 0x3d, G: 0x9f, B: 0xff, A: 0xff} // brighter blue
 	case theme.ColorNameForeground:
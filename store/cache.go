@@ -1,8 +1,54 @@
 package store
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
 // cacheFilePath returns the path to the cache file.
 func (fs FileStore[C, Cfg]) cacheFilePath() string {
-	return fs.buildFilePath(fs.cacheDir, "cache")
+	return fs.buildFilePath(fs.stateDir, "cache")
+}
+
+// migrateLegacyCache moves the cache file kept in legacyDir by earlier
+// versions into the state directory, unless the state directory already has
+// one.
+func (fs FileStore[C, Cfg]) migrateLegacyCache(legacyDir string) error {
+	legacyPath := fs.buildFilePath(legacyDir, "cache")
+	filePath := fs.cacheFilePath()
+	if legacyPath == filePath {
+		return nil
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		return nil
+	}
+	unlock, err := fs.lock(filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := os.Stat(filePath); err == nil {
+		return nil
+	}
+	if err := os.Rename(legacyPath, filePath); err != nil {
+		// the directories may be on different file systems
+		data, readErr := os.ReadFile(legacyPath)
+		if readErr != nil {
+			return readErr
+		}
+		if err := writeFileAtomic(filePath, data, 0o644); err != nil {
+			return err
+		}
+		if err := os.Remove(legacyPath); err != nil {
+			return err
+		}
+	}
+	_ = os.Remove(legacyPath + ".lock")
+	_ = os.Remove(legacyDir) // only succeeds when nothing else is left
+	logger.Infof("moved cache %s to %s", legacyPath, filePath)
+	return nil
 }
 
 // SaveCache serializes and saves the cache data to a file.
@@ -11,10 +57,47 @@ func (fs FileStore[C, Cfg]) SaveCache(data C) error {
 	return fs.saveData(data, filePath)
 }
 
-// LoadCache reads and deserializes the cache data from a file.
+// LoadCache reads and deserializes the cache data from a file. A cache that
+// can't be parsed is moved aside to a backup and an empty cache is returned,
+// since losing history beats failing every run.
 func (fs FileStore[C, Cfg]) LoadCache() (C, error) {
 	var data C
 	filePath := fs.cacheFilePath()
-	err := fs.loadData(filePath, &data, true) // Allow missing cache files
-	return data, err
+	serialized, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil // Allow missing cache files
+	}
+	if err != nil {
+		return data, err
+	}
+	if err := fs.Unmarshal(serialized, &data); err != nil {
+		var empty C
+		backupPath := fmt.Sprintf("%s.corrupt-%s", filePath, time.Now().Format("20060102-150405"))
+		if renameErr := os.Rename(filePath, backupPath); renameErr != nil {
+			return empty, fmt.Errorf("failed to back up corrupted cache %s: %w", filePath, renameErr)
+		}
+		logger.WithError(err).Warnf("cache %s is corrupted, moved it to %s and starting fresh", filePath, backupPath)
+		return empty, nil
+	}
+	return data, nil
+}
+
+// UpdateCache loads the cache, applies update to it and saves the result while
+// holding the cache lock, so menus sharing the cache don't drop each other's
+// changes. Nothing is saved when update fails.
+func (fs FileStore[C, Cfg]) UpdateCache(update func(*C) error) error {
+	filePath := fs.cacheFilePath()
+	unlock, err := fs.lock(filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := fs.LoadCache()
+	if err != nil {
+		return err
+	}
+	if err := update(&data); err != nil {
+		return err
+	}
+	return fs.saveData(data, filePath)
 }
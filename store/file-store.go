@@ -13,9 +13,9 @@ import (
 // TODO: we shoudn't need to differentiate between these two or special case them
 var logger = logrus.New()
 
-// FileStore is a store that saves data to files in the cache and config directories.
+// FileStore is a store that saves data to files in the state and config directories.
 type FileStore[Cache any, Cfg any] struct {
-	cacheDir  string
+	stateDir  string
 	configDir string
 	format    string
 }
@@ -39,13 +39,67 @@ func (fs FileStore[C, Cfg]) buildFilePath(dir, name string) string {
 	return filepath.Join(dir, name+"."+fs.format)
 }
 
-// saveData is a generic helper for saving data to a file
+// saveData is a generic helper for saving data to a file. It writes to a
+// temporary file next to filePath and renames it into place, so readers and
+// crashes never see a partially written file.
 func (fs FileStore[C, Cfg]) saveData(data any, filePath string) error {
 	serialized, err := fs.Marshal(data)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, serialized, 0o644)
+	return writeFileAtomic(filePath, serialized, 0o644)
+}
+
+// writeFileAtomic replaces filePath with data through a temporary file and a
+// rename.
+func writeFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if tmpPath != "" {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return err
+	}
+	tmpPath = ""
+	return nil
+}
+
+// lock takes an exclusive lock tied to filePath, waiting for other processes
+// and goroutines that hold it. The returned function releases it.
+func (fs FileStore[C, Cfg]) lock(filePath string) (func(), error) {
+	file, err := os.OpenFile(filePath+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	unlock, err := lockFile(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", filePath, err)
+	}
+	return func() {
+		unlock()
+		_ = file.Close()
+	}, nil
 }
 
 // loadData is a generic helper for loading data from a file
@@ -63,28 +117,43 @@ func (fs FileStore[C, Cfg]) loadData(filePath string, target any, allowMissing b
 	return fs.Unmarshal(serialized, target)
 }
 
-// NewFileStore initializes a new FileStore with directories for cache and config.
+// NewFileStore initializes a new FileStore with directories for state and
+// config under the XDG base directories.
 func NewFileStore[Cache any, Cfg any](namespace []string, format string) (*FileStore[Cache, Cfg], error) {
-	cacheDir := CacheDir("")
-	configDir := ConfigDir("")
+	return NewFileStoreIn[Cache, Cfg]("", namespace, format)
+}
+
+// NewFileStoreIn is NewFileStore with the state kept in stateDir instead of
+// the XDG state directory of namespace. An empty stateDir uses the default,
+// and only the default takes over caches kept in ~/.cache by earlier
+// versions.
+func NewFileStoreIn[Cache any, Cfg any](stateDir string, namespace []string, format string) (*FileStore[Cache, Cfg], error) {
 	if format != "json" && format != "yaml" {
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
-	for _, dir := range namespace {
-		cacheDir = filepath.Join(cacheDir, dir)
-		configDir = filepath.Join(configDir, dir)
+	nsPath := filepath.Join(namespace...)
+	migrate := stateDir == ""
+	if migrate {
+		stateDir = StateDir(nsPath)
 	}
-	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+	configDir := ConfigDir(nsPath)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
 		return nil, err
 	}
 	if err := os.MkdirAll(configDir, 0o755); err != nil {
 		return nil, err
 	}
-	return &FileStore[Cache, Cfg]{
-		cacheDir:  cacheDir,
+	fs := &FileStore[Cache, Cfg]{
+		stateDir:  stateDir,
 		configDir: configDir,
 		format:    format,
-	}, nil
+	}
+	if migrate {
+		if err := fs.migrateLegacyCache(legacyCacheDir(nsPath)); err != nil {
+			logger.WithError(err).Warn("failed to migrate the cache from ", legacyCacheDir(nsPath))
+		}
+	}
+	return fs, nil
 }
 
 func (fs FileStore[C, Cfg]) Load() (C, Cfg, error) {
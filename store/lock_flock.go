@@ -0,0 +1,24 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package store
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on file, waiting until it is free. The
+// lock is also released when the file is closed or the process exits.
+func lockFile(file *os.File) (func(), error) {
+	fd := int(file.Fd())
+	for {
+		err := syscall.Flock(fd, syscall.LOCK_EX)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func() { _ = syscall.Flock(fd, syscall.LOCK_UN) }, nil
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package store
+
+import (
+	"os"
+	"sync"
+)
+
+// fileLocks serializes access within this process, since flock isn't
+// available on this platform.
+var fileLocks sync.Mutex
+
+// lockFile only locks against other goroutines of this process.
+func lockFile(_ *os.File) (func(), error) {
+	fileLocks.Lock()
+	return fileLocks.Unlock, nil
+}
@@ -1,22 +1,66 @@
 package store
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Cache struct {
-	UsageCount       map[string]int `json:"usageCount"`
-	NotFoundAccepted []string       `json:"notFoundAccepted"`
+	UsageCount map[string]int `json:"usageCount"`
+	// LastUsed maps an accepted entry to the unix time it was last selected.
+	LastUsed         map[string]int64 `json:"lastUsed"`
+	NotFoundAccepted []string         `json:"notFoundAccepted"`
 	// LastEntry is the last entry that was selected by the user.
 	LastEntry     string `json:"lastEntry"`
 	LastEntryTime int64  `json:"lastEntryTime"`
 	// LastInput is the last input that was entered by the user.
 	LastInput string `json:"lastInput"`
+	// QueryHistory holds the queries of accepted selections, oldest first.
+	QueryHistory []string `json:"queryHistory"`
 }
 
+// MaxQueryHistory bounds the number of queries kept in the history.
+const MaxQueryHistory = 100
+
 func (c *Cache) SetLastEntry(entry string) {
 	c.LastEntry = entry
 	c.LastEntryTime = time.Now().Unix()
 }
 
+// RecordUsage bumps the usage count and last used time of an accepted entry.
+func (c *Cache) RecordUsage(entry string) {
+	if entry == "" {
+		return
+	}
+	if c.UsageCount == nil {
+		c.UsageCount = make(map[string]int)
+	}
+	if c.LastUsed == nil {
+		c.LastUsed = make(map[string]int64)
+	}
+	c.UsageCount[entry]++
+	c.LastUsed[entry] = time.Now().Unix()
+}
+
+// AddQuery appends a query to the history, moving it to the end if it is
+// already there and dropping the oldest queries beyond MaxQueryHistory.
+func (c *Cache) AddQuery(query string) {
+	if strings.TrimSpace(query) == "" {
+		return
+	}
+	history := make([]string, 0, len(c.QueryHistory)+1)
+	for _, previous := range c.QueryHistory {
+		if previous != query {
+			history = append(history, previous)
+		}
+	}
+	history = append(history, query)
+	if len(history) > MaxQueryHistory {
+		history = history[len(history)-MaxQueryHistory:]
+	}
+	c.QueryHistory = history
+}
+
 // SetLastInput sets the last input to the cache.
 func (c *Cache) SetLastInput(input string) {
 	c.LastInput = input
@@ -36,6 +80,9 @@ type Config struct {
 type Store interface {
 	SaveCache(data Cache) error
 	LoadCache() (Cache, error)
+	// UpdateCache runs a read-modify-write cycle on the cache that is safe
+	// against other processes sharing it.
+	UpdateCache(update func(*Cache) error) error
 	SaveConfig(config Config) error
 	LoadConfig() (Config, error)
 }
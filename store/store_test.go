@@ -1,8 +1,10 @@
 package store
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -326,6 +328,184 @@ func TestConcurrentAccess(t *testing.T) {
 	assert.NotNil(t, finalCache3)
 }
 
+// TestUpdateCacheConcurrent tests that concurrent read-modify-write cycles on
+// a shared cache don't drop updates
+func TestUpdateCacheConcurrent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// a store per writer, like separate gmenu processes
+			store, err := NewFileStore[Cache, Config]([]string{"gmenu", "shared"}, "yaml")
+			if err != nil {
+				t.Errorf("failed to create store: %v", err)
+				return
+			}
+			err = store.UpdateCache(func(cache *Cache) error {
+				cache.RecordUsage("item")
+				return nil
+			})
+			if err != nil {
+				t.Errorf("update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	store, err := NewFileStore[Cache, Config]([]string{"gmenu", "shared"}, "yaml")
+	require.NoError(t, err)
+	cache, err := store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, writers, cache.UsageCount["item"])
+
+	// saves leave no temporary files behind
+	entries, err := os.ReadDir(store.stateDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"cache.yaml", "cache.yaml.lock"}, names)
+}
+
+// TestUpdateCacheError tests that a failed update doesn't save the cache
+func TestUpdateCacheError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := NewFileStore[Cache, Config]([]string{"gmenu", "update-error"}, "yaml")
+	require.NoError(t, err)
+	require.NoError(t, store.SaveCache(Cache{LastInput: "kept"}))
+
+	err = store.UpdateCache(func(cache *Cache) error {
+		cache.LastInput = "dropped"
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	cache, err := store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, "kept", cache.LastInput)
+}
+
+// TestLoadCorruptedCache tests that a corrupted cache is backed up and
+// replaced by an empty one
+func TestLoadCorruptedCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := NewFileStore[Cache, Config]([]string{"gmenu", "corrupted"}, "yaml")
+	require.NoError(t, err)
+	corrupted := []byte("usageCount: [not a map\n")
+	require.NoError(t, os.WriteFile(store.cacheFilePath(), corrupted, 0o644))
+
+	cache, err := store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, Cache{}, cache)
+
+	_, err = os.Stat(store.cacheFilePath())
+	assert.True(t, os.IsNotExist(err), "corrupted cache should be moved aside")
+	backups, err := filepath.Glob(store.cacheFilePath() + ".corrupt-*")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	backup, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, corrupted, backup)
+
+	// the next update starts fresh
+	require.NoError(t, store.UpdateCache(func(cache *Cache) error {
+		cache.SetLastInput("fresh")
+		return nil
+	}))
+	cache, err = store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", cache.LastInput)
+}
+
+// TestXDGDirs tests that the store directories follow the XDG base directories
+func TestXDGDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	assert.Equal(t, filepath.Join(home, ".config", "gmenu"), ConfigDir("gmenu"))
+	assert.Equal(t, filepath.Join(home, ".local", "state", "gmenu"), StateDir("gmenu"))
+	assert.Equal(t, filepath.Join(home, ".cache", "gmenu"), CacheDir("gmenu"))
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(xdg, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(xdg, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(xdg, "cache"))
+	assert.Equal(t, filepath.Join(xdg, "config", "gmenu"), ConfigDir("gmenu"))
+	assert.Equal(t, filepath.Join(xdg, "state", "gmenu"), StateDir("gmenu"))
+	assert.Equal(t, filepath.Join(xdg, "cache", "gmenu"), CacheDir("gmenu"))
+
+	// relative paths are invalid and ignored
+	t.Setenv("XDG_STATE_HOME", "state")
+	assert.Equal(t, filepath.Join(home, ".local", "state", "gmenu"), StateDir("gmenu"))
+
+	store, err := NewFileStore[Cache, Config]([]string{"gmenu", "xdg"}, "yaml")
+	require.NoError(t, err)
+	require.NoError(t, store.SaveCache(Cache{LastInput: "saved"}))
+	assert.FileExists(t, filepath.Join(home, ".local", "state", "gmenu", "xdg", "cache.yaml"))
+}
+
+// TestNewFileStoreIn tests keeping the state in a custom directory
+func TestNewFileStoreIn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	stateDir := filepath.Join(t.TempDir(), "custom")
+
+	store, err := NewFileStoreIn[Cache, Config](stateDir, []string{"gmenu", "custom"}, "yaml")
+	require.NoError(t, err)
+	require.NoError(t, store.SaveCache(Cache{LastInput: "saved"}))
+	assert.FileExists(t, filepath.Join(stateDir, "cache.yaml"))
+}
+
+// TestLegacyCacheMigration tests that a cache kept in ~/.cache by earlier
+// versions is moved to the state directory once
+func TestLegacyCacheMigration(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	legacyDir := filepath.Join(home, ".cache", "gmenu", "legacy")
+	require.NoError(t, os.MkdirAll(legacyDir, 0o755))
+	legacyPath := filepath.Join(legacyDir, "cache.yaml")
+	require.NoError(t, os.WriteFile(legacyPath, []byte("lastinput: old query\n"), 0o644))
+
+	store, err := NewFileStore[Cache, Config]([]string{"gmenu", "legacy"}, "yaml")
+	require.NoError(t, err)
+	cache, err := store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, "old query", cache.LastInput)
+	assert.NoFileExists(t, legacyPath)
+	assert.NoDirExists(t, legacyDir)
+
+	// an existing state cache wins over a legacy one
+	require.NoError(t, os.MkdirAll(legacyDir, 0o755))
+	require.NoError(t, os.WriteFile(legacyPath, []byte("lastinput: stale query\n"), 0o644))
+	store, err = NewFileStore[Cache, Config]([]string{"gmenu", "legacy"}, "yaml")
+	require.NoError(t, err)
+	cache, err = store.LoadCache()
+	require.NoError(t, err)
+	assert.Equal(t, "old query", cache.LastInput)
+	assert.FileExists(t, legacyPath)
+
+	// a custom state directory leaves the legacy cache in place
+	stateDir := filepath.Join(t.TempDir(), "custom")
+	store, err = NewFileStoreIn[Cache, Config](stateDir, []string{"gmenu", "legacy"}, "yaml")
+	require.NoError(t, err)
+	cache, err = store.LoadCache()
+	require.NoError(t, err)
+	assert.Empty(t, cache.LastInput)
+	assert.FileExists(t, legacyPath)
+	assert.NoFileExists(t, filepath.Join(stateDir, "cache.yaml"))
+}
+
 // TestCacheStructure tests the cache data structure
 func TestCacheStructure(t *testing.T) {
 	cache := &Cache{
@@ -352,6 +532,35 @@ func TestCacheStructure(t *testing.T) {
 	assert.Len(t, cache.NotFoundAccepted, 4)
 }
 
+// TestCacheRecordUsage tests usage tracking for accepted entries
+func TestCacheRecordUsage(t *testing.T) {
+	cache := &Cache{}
+	cache.RecordUsage("item1")
+	cache.RecordUsage("item1")
+	cache.RecordUsage("item2")
+	cache.RecordUsage("")
+
+	assert.Equal(t, map[string]int{"item1": 2, "item2": 1}, cache.UsageCount)
+	assert.Len(t, cache.LastUsed, 2)
+	assert.NotZero(t, cache.LastUsed["item1"])
+}
+
+// TestCacheAddQuery tests the bounded query history
+func TestCacheAddQuery(t *testing.T) {
+	cache := &Cache{}
+	cache.AddQuery("one")
+	cache.AddQuery("two")
+	cache.AddQuery("  ")
+	cache.AddQuery("one")
+	assert.Equal(t, []string{"two", "one"}, cache.QueryHistory)
+
+	for i := 0; i < MaxQueryHistory+5; i++ {
+		cache.AddQuery(fmt.Sprintf("query %d", i))
+	}
+	assert.Len(t, cache.QueryHistory, MaxQueryHistory)
+	assert.Equal(t, fmt.Sprintf("query %d", MaxQueryHistory+4), cache.QueryHistory[MaxQueryHistory-1])
+}
+
 // TestConfigStructure tests the config data structure
 func TestConfigStructure(t *testing.T) {
 	config := &Config{
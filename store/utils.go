@@ -5,10 +5,50 @@ import (
 	"path/filepath"
 )
 
+// ConfigHome returns $XDG_CONFIG_HOME, or ~/.config when it isn't set.
+func ConfigHome() string {
+	return xdgHome("XDG_CONFIG_HOME", ".config")
+}
+
+// CacheHome returns $XDG_CACHE_HOME, or ~/.cache when it isn't set. gmenu
+// itself keeps nothing there: its history must outlive a cache cleanup, so it
+// lives in StateHome, and its lock files and sockets live in the temporary
+// directory so they are gone after a reboot.
+func CacheHome() string {
+	return xdgHome("XDG_CACHE_HOME", ".cache")
+}
+
+// StateHome returns $XDG_STATE_HOME, or ~/.local/state when it isn't set.
+func StateHome() string {
+	return xdgHome("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
 func ConfigDir(namespace string) string {
-	return filepath.Join(os.Getenv("HOME"), ".config", namespace)
+	return filepath.Join(ConfigHome(), namespace)
 }
 
+// CacheDir returns the namespace directory under CacheHome.
 func CacheDir(namespace string) string {
+	return filepath.Join(CacheHome(), namespace)
+}
+
+// StateDir returns the directory for history and other data that should
+// outlive a cache cleanup.
+func StateDir(namespace string) string {
+	return filepath.Join(StateHome(), namespace)
+}
+
+// xdgHome returns the base directory named by env, falling back to dir under
+// the home directory. Relative paths are ignored as the XDG spec requires.
+func xdgHome(env, dir string) string {
+	if value := os.Getenv(env); filepath.IsAbs(value) {
+		return value
+	}
+	return filepath.Join(os.Getenv("HOME"), dir)
+}
+
+// legacyCacheDir is where caches were kept before the XDG state directory
+// was used.
+func legacyCacheDir(namespace string) string {
 	return filepath.Join(os.Getenv("HOME"), ".cache", namespace)
 }